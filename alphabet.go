@@ -0,0 +1,132 @@
+/** ALPHABETS
+
+Every cipher in The Code Book is written for the 26 letters of the English alphabet, and so was every cipher in this package: all of
+the arithmetic was done on 'A' and 'Z' directly. There's nothing about a Caesar shift or a Vigenere square that actually cares which
+letters it's working with though, so long as they have an order. Julius Caesar himself would've shifted through a Latin alphabet with
+no J, U or W, and a Caesar cipher over Greek or Cyrillic works exactly the same way
+
+An Alphabet is just that: an ordered set of letters, plus a way to look up where any letter sits in it. Ciphers that do arithmetic on
+letters take one through the WithAlphabet option, and use RomanAlphabet (A-Z, the old ROMANALPHA) when they aren't given one
+
+When text is cleaned up for a cipher, each character is checked against the alphabet as-is, then upper-cased, then run through the
+alphabet's folds. Folds cover letters that should be treated as some other letter, like final sigma in Greek or J in Latin
+*/
+
+package ciphers
+
+import (
+	"errors"
+	"unicode"
+)
+
+type Alphabet struct {
+	letters []rune
+	index map[rune]int
+	folds map[rune]rune
+}
+
+var (
+	// A-Z, what every cipher uses by default
+	RomanAlphabet *Alphabet = mustAlphabet(ROMANALPHA, nil)
+	// A-Z followed by 0-9
+	AlphanumericAlphabet *Alphabet = mustAlphabet(ROMANALPHA + "0123456789", nil)
+	// The 25 letters of a Playfair square. J is written as I
+	PlayfairAlphabet *Alphabet = mustAlphabet("ABCDEFGHIKLMNOPQRSTUVWXYZ", map[rune]rune{'J': 'I'})
+	// The 23 letter classical Latin alphabet. J is written as I, and U as V. W didn't exist yet, so it's written as V too
+	LatinAlphabet *Alphabet = mustAlphabet("ABCDEFGHIKLMNOPQRSTVXYZ", map[rune]rune{'J': 'I', 'U': 'V', 'W': 'V'})
+	// The 24 letter Greek alphabet. Letters with a tonos or diaeresis are treated as the plain letter
+	GreekAlphabet *Alphabet = mustAlphabet("ΑΒΓΔΕΖΗΘΙΚΛΜΝΞΟΠΡΣΤΥΦΧΨΩ", map[rune]rune{
+		'Ά': 'Α', 'Έ': 'Ε', 'Ή': 'Η', 'Ί': 'Ι', 'Ϊ': 'Ι', 'Ό': 'Ο', 'Ύ': 'Υ', 'Ϋ': 'Υ', 'Ώ': 'Ω',
+	})
+	// The 33 letter Russian alphabet
+	CyrillicAlphabet *Alphabet = mustAlphabet("АБВГДЕЁЖЗИЙКЛМНОПРСТУФХЦЧШЩЪЫЬЭЮЯ", nil)
+	// The 22 letters of the Hebrew alphabet. The final forms are treated as the regular letter
+	HebrewAlphabet *Alphabet = mustAlphabet("אבגדהוזחטיכלמנסעפצקרשת", map[rune]rune{
+		'ך': 'כ', 'ם': 'מ', 'ן': 'נ', 'ף': 'פ', 'ץ': 'צ',
+	})
+)
+
+func mustAlphabet(letters string, folds map[rune]rune) *Alphabet {
+    alphabet, err := NewFoldedAlphabet(letters, folds)
+    if err != nil {panic(err)}
+    return alphabet
+}
+
+// Make an alphabet out of the given letters, in order. Every letter has to be unique
+func NewAlphabet(letters string) (*Alphabet, error) {
+    return NewFoldedAlphabet(letters, nil)
+}
+
+// Make an alphabet out of the given letters, where each key of folds is treated as if it were its value. Folds have to land on a
+// letter of the alphabet, and can't replace one
+func NewFoldedAlphabet(letters string, folds map[rune]rune) (*Alphabet, error) {
+    if len(letters) <= 0 {return nil, errors.New("given empty string")}
+    var alphabet *Alphabet = &Alphabet{
+        letters: []rune(letters),
+        index: make(map[rune]int),
+        folds: make(map[rune]rune, len(folds)),
+    }
+    if len(alphabet.letters) < 2 {return nil, errors.New("alphabet needs at least 2 letters")}
+
+    for i, cur := range alphabet.letters {
+        if _, exists := alphabet.index[cur]; exists {return nil, errors.New("letter " + string(cur) + " is in the alphabet more than once")}
+        alphabet.index[cur] = i
+    }
+    for from, to := range folds {
+        if _, exists := alphabet.index[from]; exists {return nil, errors.New("can't fold " + string(from) + ", it's already in the alphabet")}
+        if _, exists := alphabet.index[to]; !exists {return nil, errors.New("can't fold " + string(from) + " into " + string(to) + ", which isn't in the alphabet")}
+        alphabet.folds[from] = to
+    }
+
+    return alphabet, nil
+}
+
+// Number of letters in the alphabet
+func (a *Alphabet) Len() int {
+    return len(a.letters)
+}
+
+// The letters of the alphabet, in order
+func (a *Alphabet) String() string {
+    return string(a.letters)
+}
+
+// Where the letter sits in the alphabet. The letter has to be exactly one of the alphabet's letters; use Normalize first for text
+// that hasn't been cleaned up
+func (a *Alphabet) Index(letter rune) (int, bool) {
+    ind, exists := a.index[letter]
+    return ind, exists
+}
+
+// The letter at the given index. The index wraps around in both directions, so Rune(-1) is the last letter and Rune(Len()) is
+// the first, which is what every shift cipher wants anyway
+func (a *Alphabet) Rune(ind int) rune {
+    ind %= len(a.letters)
+    if ind < 0 {ind += len(a.letters)}
+    return a.letters[ind]
+}
+
+// Turn a character into the letter of the alphabet it stands for, if it stands for one at all
+func (a *Alphabet) Normalize(cur rune) (rune, bool) {
+    if _, exists := a.index[cur]; exists {return cur, true}
+
+    cur = unicode.ToUpper(cur)
+    if _, exists := a.index[cur]; exists {return cur, true}
+    if folded, exists := a.folds[cur]; exists {return folded, true}
+
+    return cur, false
+}
+
+// Same as stripnonalpha, but for any alphabet: normalize every letter and throw away everything else
+func (a *Alphabet) Strip(text string) (string, error) {
+    if len(text) <= 0 {return "", errors.New("given empty string")}
+    var res []rune = make([]rune, 0, len(text))
+
+    for _, cur := range text {
+        letter, ok := a.Normalize(cur)
+        if !ok {continue}
+        res = append(res, letter)
+    }
+
+    return string(res), nil
+}
@@ -0,0 +1,106 @@
+package ciphers
+
+import (
+	"testing"
+)
+
+func TestNewAlphabet(t *testing.T) {
+	alphabet, err := NewAlphabet("ABC")
+	if alphabet == nil || alphabet.Len() != 3 || err != nil {
+		t.Errorf("Could not make alphabet: %v (%v)", alphabet, err)
+	}
+
+	if _, err := NewAlphabet("ABCA"); err == nil {
+		t.Errorf("Made an alphabet with a repeated letter")
+	}
+	if _, err := NewAlphabet(""); err == nil {
+		t.Errorf("Made an empty alphabet")
+	}
+	if _, err := NewFoldedAlphabet("ABC", map[rune]rune{'D': 'E'}); err == nil {
+		t.Errorf("Made an alphabet that folds into a letter it doesn't have")
+	}
+	if _, err := NewFoldedAlphabet("ABC", map[rune]rune{'A': 'B'}); err == nil {
+		t.Errorf("Made an alphabet that folds away one of its own letters")
+	}
+}
+
+func TestAlphabetNormalize(t *testing.T) {
+	tests := []struct {
+		alphabet *Alphabet
+		in, out string
+	}{
+		{RomanAlphabet, "Veni, vidi, vici", "VENIVIDIVICI"},
+		{LatinAlphabet, "Iulius Caesar, Juno, Wotan", "IVLIVSCAESARIVNOVOTAN"},
+		{GreekAlphabet, "Αλέξανδρος ο Μέγας", "ΑΛΕΞΑΝΔΡΟΣΟΜΕΓΑΣ"},
+		{CyrillicAlphabet, "Привет, мир!", "ПРИВЕТМИР"},
+		{HebrewAlphabet, "שָׁלוֹם", "שלומ"},
+		{AlphanumericAlphabet, "Agent 007", "AGENT007"},
+	}
+
+	for _, test := range tests {
+		res, err := test.alphabet.Strip(test.in)
+		if res != test.out || err != nil {
+			t.Errorf("Got incorrect string from stripping %v with %v: %v (%v)", test.in, test.alphabet, res, err)
+		}
+	}
+
+	if res := RomanAlphabet.Rune(-1); res != 'Z' {
+		t.Errorf("Index -1 should wrap around to Z, got %c", res)
+	}
+	if ind, exists := GreekAlphabet.Index('Ω'); ind != 23 || !exists {
+		t.Errorf("Got incorrect index for omega: %v %v", ind, exists)
+	}
+}
+
+func TestAlphabetCiphers(t *testing.T) {
+	// Caesar the way Caesar would have written it
+	res1, err := CaesarEncrypt("VENI, VIDI, VICI", WithAlphabet(LatinAlphabet))
+	if res1 != "ZHQMZMGMZMFM" || err != nil {
+		t.Errorf("Got incorrect string from Latin Caesar encryption: %v (%v)", res1, err)
+	}
+	res2, err := CaesarDecrypt(res1, WithAlphabet(LatinAlphabet))
+	if res2 != "VENIVIDIVICI" || err != nil {
+		t.Errorf("Got incorrect string from Latin Caesar decryption: %v (%v)", res2, err)
+	}
+
+	// Jeremiah 25:26 writes Babel as Sheshach, the first recorded use of Atbash
+	res3, err := Atbash("בבל", WithAlphabet(HebrewAlphabet))
+	if res3 != "ששכ" || err != nil {
+		t.Errorf("Got incorrect string from Hebrew Atbash: %v (%v)", res3, err)
+	}
+
+	const GREEKTEXT string = "ΓΝΩΘΙΣΕΑΥΤΟΝ"
+	res4, err := VigenereEncrypt(GREEKTEXT, "ΔΕΛΦΟΙ", WithAlphabet(GreekAlphabet))
+	if len(res4) <= 0 || err != nil {
+		t.Errorf("Got incorrect string from Greek Vigenere encryption: %v (%v)", res4, err)
+	}
+	res5, err := VigenereDecrypt(res4, "ΔΕΛΦΟΙ", WithAlphabet(GreekAlphabet))
+	if res5 != GREEKTEXT || err != nil {
+		t.Errorf("Got incorrect string from Greek Vigenere decryption: %v (%v)", res5, err)
+	}
+
+	const CYRILLICTEXT string = "ВСЕСЧАСТЛИВЫЕСЕМЬИПОХОЖИДРУГНАДРУГА"
+	res6, err := KeyphraseEncrypt(CYRILLICTEXT, "ТОЛСТОЙ", WithAlphabet(CyrillicAlphabet))
+	if len(res6) <= 0 || err != nil {
+		t.Errorf("Got incorrect string from Cyrillic Keyphrase encryption: %v (%v)", res6, err)
+	}
+	res7, err := KeyphraseDecrypt(res6, "ТОЛСТОЙ", WithAlphabet(CyrillicAlphabet))
+	if res7 != CYRILLICTEXT || err != nil {
+		t.Errorf("Got incorrect string from Cyrillic Keyphrase decryption: %v (%v)", res7, err)
+	}
+
+	// 23 letters can't all be paired up, so one of them has to map to itself
+	res8, key, err := MVPCEncrypt("GALLIA EST OMNIS DIVISA IN PARTES TRES", WithAlphabet(LatinAlphabet))
+	if len(res8) <= 0 || len(key) != LatinAlphabet.Len() || err != nil {
+		t.Errorf("Got incorrect output from Latin MVPC encryption: %v %v (%v)", res8, key, err)
+	}
+	res9, err := MVPCDecrypt(res8, key)
+	if res9 != "GALLIAESTOMNISDIVISAINPARTESTRES" || err != nil {
+		t.Errorf("Got incorrect string from Latin MVPC decryption: %v (%v)", res9, err)
+	}
+
+	res10, err := ROTX("AGENT007", 10, WithAlphabet(AlphanumericAlphabet))
+	if res10 != "KQOX3AAH" || err != nil {
+		t.Errorf("Got incorrect string from alphanumeric ROTX: %v (%v)", res10, err)
+	}
+}
@@ -0,0 +1,1024 @@
+/** CRYPTANALYSIS
+
+Every cipher in this package comes with its own weakness, and most of The Code Book is the story of somebody finding it. The
+functions in this file are those stories turned into code: given nothing but a ciphertext, they work out the most likely keys and
+hand back what the plaintext would be under each one
+
+Breaking a cipher without a key always comes down to the same question, asked over and over: "does this look like English?" A
+computer can't read, so it answers with statistics instead. Every letter has a known frequency in English (E about 12.7% of the
+time, Z about 0.07%), so the chance of a piece of text being English is the product of the chances of each of its letters. Those
+numbers get very small very quickly, so scores are kept as log-probabilities and added up instead, which also means a higher
+(less negative) score is always better
+
+The statistics come from the language package. Every cracker assumes English unless it's given WithLanguage, so the same attacks
+work just as well on a Caesar cipher over some actual Caesar
+
+Crackers give back every candidate they tried, best first, so you can look further down the list when the top guess is wrong.
+That happens more than you'd think with short ciphertexts
+
+Attacks implemented in this file:
+    - Caesar / ROTX: brute force
+    - Affine: brute force on quadgrams
+    - Rail fence: brute force on quadgrams
+    - Hill: known plaintext, solved mod 2 and mod 13
+    - Vigenere, Beaufort and Gronsfeld: Kasiski examination and the index of coincidence
+    - Simple substitution: hill climbing on quadgrams
+    - Homophonic substitution: simulated annealing on quadgrams and letter frequencies
+    - Playfair: simulated annealing on quadgrams
+*/
+
+package ciphers
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"strings"
+
+	"github.com/realconebob/ciphers/language"
+)
+
+// One possible solution from a cracker. Score is how English the plaintext looks (higher is better), which is only meaningful
+// compared against other candidates from the same cracker
+type Candidate struct {
+	Key string
+	Plaintext string
+	Score float64
+}
+
+// Log-probability of the letters of text under the language's single letter frequencies. Lowercase letters count the same as
+// uppercase, and anything that isn't a letter is ignored
+func unigramScore(text string, lang *language.Model) float64 {
+    score, _ := lang.ScoreN(text, 1)
+    return score
+}
+
+// Sort candidates best first. Ties keep the order they were found in
+func rankCandidates(candidates []Candidate) {
+    slices.SortStableFunc(candidates, func(a, b Candidate) int {
+        switch {
+        case a.Score > b.Score: return -1
+        case a.Score < b.Score: return 1
+        default: return 0
+        }
+    })
+}
+
+func indexOfCoincidence(text []rune) float64 {
+    if len(text) < 2 {return 0}
+    var counts map[rune]int = make(map[rune]int)
+    for _, cur := range text {
+        counts[cur]++
+    }
+
+    var sum int
+    for _, count := range counts {
+        sum += count * (count - 1)
+    }
+    return float64(sum) / float64(len(text) * (len(text) - 1))
+}
+
+// The chance that two letters picked at random from text are the same letter, ignoring anything that isn't a letter. English
+// comes out around language.English.IndexOfCoincidence() no matter what it's enciphered with, as long as the cipher only ever
+// swaps one letter for another (monoalphabetic ciphers and transpositions). A polyalphabetic cipher smears the letters out and
+// drags it down towards 1/26
+func IndexOfCoincidence(text string) (float64, error) {
+    stripped, err := stripnonalpha(text)
+    if err != nil {return 0, err}
+    if len([]rune(stripped)) < 2 {return 0, errors.New("need at least 2 letters")}
+
+    return indexOfCoincidence([]rune(stripped)), nil
+}
+
+/* A Caesar cipher only has 25 keys, so there's no cleverness needed: try them all, and see which one comes out looking the most
+like English. Even a sentence is usually enough for the right one to win by a mile
+*/
+
+// Try every offset on a ROTX (or Caesar) ciphertext. Each candidate's key is the offset the plaintext was encrypted with, written
+// the same way ROTXCipher.Key() writes it, so Caesar comes out as "3". The options are passed on to ROTX, so PreserveFormat gives
+// back plaintexts with their punctuation still in place
+func CrackROTX(ciphertext string, opts ...Option) ([]Candidate, error) {
+    o := getOptions(opts)
+    stripped, err := o.alphabet.Strip(ciphertext)
+    if err != nil {return nil, err}
+    if len(stripped) <= 0 {return nil, errors.New("ciphertext has no letters in it")}
+    var candidates []Candidate = make([]Candidate, 0, o.alphabet.Len() - 1)
+
+    for offset := 1; offset < o.alphabet.Len(); offset++ {
+        plaintext, err := ROTX(ciphertext, rune(-offset), opts...)
+        if err != nil {return nil, err}
+        candidates = append(candidates, Candidate{Key: fmt.Sprint(offset), Plaintext: plaintext, Score: unigramScore(plaintext, o.language)})
+    }
+
+    rankCandidates(candidates)
+    return candidates, nil
+}
+
+/* The affine cipher isn't much better: 12 multipliers and 26 offsets make 312 keys, which takes no time at all to try. Single letter
+frequencies can't always tell apart two keys that happen to swap a few common letters around on a short ciphertext, so the
+candidates are scored on quadgrams instead
+*/
+
+// Try every key on an affine ciphertext. Each candidate's key is written the same way AffineCipher.Key() writes it,
+// "MULTIPLIER,OFFSET", with both in the range of the alphabet. Takes WithAlphabet, WithLanguage and PreserveFormat, the same as
+// CrackROTX
+func CrackAffine(ciphertext string, opts ...Option) ([]Candidate, error) {
+    o := getOptions(opts)
+    stripped, err := o.alphabet.Strip(ciphertext)
+    if err != nil {return nil, err}
+    if len(stripped) <= 0 {return nil, errors.New("ciphertext has no letters in it")}
+    var candidates []Candidate
+
+    for multiplier := 1; multiplier < o.alphabet.Len(); multiplier++ {
+        if gcd(multiplier, o.alphabet.Len()) != 1 {continue}
+        for offset := 0; offset < o.alphabet.Len(); offset++ {
+            key := AffineKey{Multiplier: multiplier, Offset: offset}
+            plaintext, err := AffineDecrypt(ciphertext, key, opts...)
+            if err != nil {return nil, err}
+            candidates = append(candidates, Candidate{Key: fmt.Sprintf("%v,%v", multiplier, offset), Plaintext: plaintext, Score: o.language.Score(plaintext)})
+        }
+    }
+
+    rankCandidates(candidates)
+    return candidates, nil
+}
+
+/* A rail fence has barely more keys than a Caesar cipher, so it gets brute forced the same way. The difference is in the scoring:
+a transposition doesn't change which letters are in the text, only their order, so every candidate has exactly the same letter
+frequencies and only looking at runs of letters (quadgrams) can tell them apart
+*/
+
+// The most rails CrackRailfence tries
+const MAXRAILS int = 50
+
+// Try every number of rails and every offset on a rail fence ciphertext. Each candidate's key is written the same way
+// RailfenceCipher.Key() writes it, "RAILS,OFFSET". Keys that give the same plaintext as a key already tried (which happens a lot
+// once there are more rails than letters) are left out. Takes WithLanguage
+func CrackRailfence(ciphertext string, opts ...Option) ([]Candidate, error) {
+    o := getOptions(opts)
+    stripped, err := o.alphabet.Strip(ciphertext)
+    if err != nil {return nil, err}
+    if len([]rune(stripped)) < 3 {return nil, errors.New("ciphertext is too short to crack")}
+
+    var candidates []Candidate
+    var tried map[string]bool = make(map[string]bool)
+    for rails := 2; rails <= min(len([]rune(stripped)) - 1, MAXRAILS); rails++ {
+        for offset := 0; offset < 2 * (rails - 1); offset++ {
+            plaintext, err := RailfenceDecryptN(stripped, rails, offset, opts...)
+            if err != nil {return nil, err}
+            if tried[plaintext] {continue}
+            tried[plaintext] = true
+
+            candidates = append(candidates, Candidate{Key: fmt.Sprintf("%v,%v", rails, offset), Plaintext: plaintext, Score: o.language.Score(plaintext)})
+        }
+    }
+
+    rankCandidates(candidates)
+    return candidates, nil
+}
+
+/* The Hill cipher falls to known plaintext. Encrypting is multiplying by the key, so if P is a matrix made of plaintext blocks (one
+per column) and C is the ciphertext blocks that go with them, then C = K × P, and the key comes straight out as K = C × P⁻¹. All it
+takes is n blocks that make an invertible P
+
+Except that inverting P mod 26 is fussy, because 26 isn't prime: dividing by 2 or 13 doesn't work, so plenty of perfectly good sets
+of blocks can't be inverted. The way around that is to solve the problem twice, once mod 2 and once mod 13. Both of those are prime,
+so ordinary Gaussian elimination works, and it can use every block available instead of exactly n of them. Then the Chinese
+remainder theorem glues the two answers back together: there's exactly one number mod 26 for each pair of remainders mod 2 and 13
+*/
+
+// Solve rows × X = results mod prime for X, by Gaussian elimination. rows has one equation per row, and there have to be enough of
+// them to pin down every unknown
+func solveModPrime(rows, results [][]int, prime int) ([][]int, error) {
+    unknowns := len(rows[0])
+    var aug [][]int = make([][]int, len(rows))
+    for i := range rows {
+        for _, cur := range append(slices.Clone(rows[i]), results[i]...) {
+            aug[i] = append(aug[i], modulo(cur, prime))
+        }
+    }
+
+    for col := 0; col < unknowns; col++ {
+        pivot := slices.IndexFunc(aug[col:], func(row []int) bool {return row[col] != 0})
+        if pivot < 0 {return nil, fmt.Errorf("not enough different blocks to find the key (mod %v)", prime)}
+        aug[col], aug[col + pivot] = aug[col + pivot], aug[col]
+
+        inverse, _ := modInverse(aug[col][col], prime)
+        for j := range aug[col] {
+            aug[col][j] = aug[col][j] * inverse % prime
+        }
+        for i := range aug {
+            if i == col || aug[i][col] == 0 {continue}
+            factor := aug[i][col]
+            for j := range aug[i] {
+                aug[i][j] = modulo(aug[i][j] - factor * aug[col][j], prime)
+            }
+        }
+    }
+
+    // Every equation left over has to agree with the answer, or the plaintext and ciphertext don't belong together
+    for _, row := range aug[unknowns:] {
+        if slices.ContainsFunc(row, func(cur int) bool {return cur != 0}) {return nil, errors.New("plaintext and ciphertext don't match any key")}
+    }
+
+    var res [][]int
+    for _, row := range aug[:unknowns] {
+        res = append(res, row[unknowns:])
+    }
+    return res, nil
+}
+
+// Recover an n×n Hill key matrix from a plaintext and the ciphertext it encrypts to. Needs at least n blocks of n letters, and
+// usually a few more than that, since some sets of blocks don't say enough about the key. Any letters past the last block that both
+// texts fill are ignored
+func CrackHill(plaintext, ciphertext string, size int) ([][]int, error) {
+    if size <= 0 {return nil, errors.New("block size has to be at least 1")}
+    plainstripped, err := stripnonalpha(plaintext)
+    if err != nil {return nil, err}
+    cipherstripped, err := stripnonalpha(ciphertext)
+    if err != nil {return nil, err}
+    blocks := min(len(plainstripped), len(cipherstripped)) / size
+    if blocks < size {return nil, fmt.Errorf("need at least %v blocks of %v letters, only have %v", size, size, blocks)}
+
+    // C = K × P turned on its side is Pᵀ × Kᵀ = Cᵀ, which has one plaintext block per equation
+    var rows, results [][]int = make([][]int, blocks), make([][]int, blocks)
+    for i := range blocks {
+        for j := range size {
+            plain, _ := RomanAlphabet.Index(rune(plainstripped[i * size + j]))
+            cipher, _ := RomanAlphabet.Index(rune(cipherstripped[i * size + j]))
+            rows[i] = append(rows[i], plain)
+            results[i] = append(results[i], cipher)
+        }
+    }
+
+    var matrix [][]int = make([][]int, size)
+    for i := range matrix {
+        matrix[i] = make([]int, size)
+    }
+    for _, prime := range []int{2, 13} {
+        transposed, err := solveModPrime(rows, results, prime)
+        if err != nil {return nil, err}
+
+        // 13 is 1 mod 2 and 0 mod 13, and 14 is 0 mod 2 and 1 mod 13, so each answer gets scaled by its own one of those
+        var unit int = map[int]int{2: 13, 13: 14}[prime]
+        for i := range matrix {
+            for j := range matrix[i] {
+                matrix[i][j] = modulo(matrix[i][j] + unit * transposed[j][i], ROMANWIDTH)
+            }
+        }
+    }
+
+    if _, err := hillInverse(matrix); err != nil {return nil, err}
+    return matrix, nil
+}
+
+/* Babbage (and Kasiski, who published first) noticed that a Vigenere ciphertext repeats itself whenever the same bit of plaintext
+happens to line up with the same bit of the key. That can only happen when the distance between the two is a multiple of the key
+length, so the key length has to divide most of the distances between repeated sequences
+
+Friedman's index of coincidence gets at the same thing from the other direction. Split the ciphertext into columns, one per letter
+of the key; if the guess at the key length is right, each column was enciphered with a single Caesar shift, so it has the index of
+coincidence of English. If it's wrong, each column is a mix of shifts and looks random
+
+Once the key length is known, each column is a Caesar cipher, and every one of its 26 shifts can just be tried to see which one
+makes the letters come out with English frequencies. Rather than working out which shift each key letter makes, every key letter
+is just tried with the tableau's own rule, so the same attack works on Beaufort and variant Beaufort as well as Vigenere. The
+Gronsfeld cipher is even easier, with only 10 key letters to try
+*/
+
+// How many of the distances between repeated trigrams each key length divides
+func kasiski(text []rune, maxlen int) map[int]int {
+    var seen map[string][]int = make(map[string][]int)
+    var factors map[int]int = make(map[int]int)
+
+    for i := 0; i + 3 <= len(text); i++ {
+        trigram := string(text[i:i + 3])
+        for _, prev := range seen[trigram] {
+            distance := i - prev
+            for length := 2; length <= maxlen; length++ {
+                if distance % length == 0 {factors[length]++}
+            }
+        }
+        seen[trigram] = append(seen[trigram], i)
+    }
+
+    return factors
+}
+
+// Split text into length columns, the first holding letters 0, length, 2*length...
+func columns(text []rune, length int) [][]rune {
+    var res [][]rune = make([][]rune, length)
+    for i, cur := range text {
+        res[i % length] = append(res[i % length], cur)
+    }
+    return res
+}
+
+// The most likely key lengths, best first: the ones whose columns look the most like English, plus the ones Kasiski points at
+func vigenereKeyLengths(text []rune) []int {
+    maxlen := min(20, len(text) / 4)
+    if maxlen < 1 {maxlen = 1}
+
+    type guess struct {
+        length int
+        ioc float64
+    }
+    var guesses []guess
+    for length := 1; length <= maxlen; length++ {
+        var total float64
+        cols := columns(text, length)
+        for _, col := range cols {
+            total += indexOfCoincidence(col)
+        }
+        guesses = append(guesses, guess{length, total / float64(length)})
+    }
+    slices.SortStableFunc(guesses, func(a, b guess) int {
+        switch {
+        case a.ioc > b.ioc: return -1
+        case a.ioc < b.ioc: return 1
+        default: return 0
+        }
+    })
+
+    var lengths []int
+    for _, cur := range guesses[:min(6, len(guesses))] {
+        lengths = append(lengths, cur.length)
+    }
+
+    // Every multiple of the key length divides the distances too, so take the longest lengths that divide nearly as many as the best
+    factors := kasiski(text, maxlen)
+    var best int
+    for _, count := range factors {
+        best = max(best, count)
+    }
+    for length := maxlen; length >= 2 && best > 0; length-- {
+        if float64(factors[length]) >= 0.8 * float64(best) && !slices.Contains(lengths, length) {
+            lengths = append(lengths, length)
+            if len(lengths) >= 9 {break}
+        }
+    }
+
+    return lengths
+}
+
+// The shortest key that repeats to make key, so a key found at length 12 that's really a 6 letter key repeated twice comes out as
+// the 6 letter key
+func shortestPeriod(key []rune) []rune {
+    for period := 1; period < len(key); period++ {
+        if len(key) % period != 0 {continue}
+        repeats := true
+        for i := period; i < len(key) && repeats; i++ {
+            repeats = key[i] == key[i - period]
+        }
+        if repeats {return key[:period]}
+    }
+    return key
+}
+
+// Break a ciphertext enciphered on a tableau with a repeating key, trying only the key letters in keys (indices into the alphabet)
+func crackTableau(ciphertext string, keys []int, o options) ([]Candidate, error) {
+    stripped, err := o.alphabet.Strip(ciphertext)
+    if err != nil {return nil, err}
+    text := []rune(stripped)
+    if len(text) < 2 {return nil, errors.New("ciphertext is too short to crack")}
+
+    var candidates []Candidate
+    var tried map[string]bool = make(map[string]bool)
+
+    for _, length := range vigenereKeyLengths(text) {
+        var key []rune = make([]rune, length)
+
+        for i, col := range columns(text, length) {
+            var bestscore float64 = math.Inf(-1)
+            var plain []rune = make([]rune, len(col))
+            for _, k := range keys {
+                for j, cur := range col {
+                    ind, _ := o.alphabet.Index(cur)
+                    plain[j] = o.alphabet.Rune(o.tableau.decrypt(ind, k))
+                }
+                score := unigramScore(string(plain), o.language)
+                if score > bestscore {key[i], bestscore = o.alphabet.Rune(k), score}
+            }
+        }
+
+        key = shortestPeriod(key)
+        if tried[string(key)] {continue}
+        tried[string(key)] = true
+
+        plaintext, err := VigenereDecrypt(stripped, string(key), WithTableau(o.tableau), WithAlphabet(o.alphabet))
+        if err != nil {return nil, err}
+        // A longer key can always be bent to fit the letter frequencies a little better, so every key letter has to pay for itself.
+        // Spelling out one key letter out of 26 costs log(26), which is a lot more than a wrong key length ever gains, and a lot less
+        // than the right one does
+        score := unigramScore(plaintext, o.language) - float64(len(key)) * math.Log(float64(len(keys)))
+        candidates = append(candidates, Candidate{Key: string(key), Plaintext: plaintext, Score: score})
+    }
+
+    rankCandidates(candidates)
+    return candidates, nil
+}
+
+// Break a Vigenere ciphertext without the key. Returns every key that was tried, with the plaintext it gives, best first. Takes
+// WithAlphabet, WithLanguage, and WithTableau to break Beaufort and variant Beaufort ciphertexts the same way
+func CrackVigenere(ciphertext string, opts ...Option) ([]Candidate, error) {
+    o := getOptions(opts)
+    var keys []int
+    for k := 0; k < o.alphabet.Len(); k++ {
+        keys = append(keys, k)
+    }
+    return crackTableau(ciphertext, keys, o)
+}
+
+// Break a Gronsfeld ciphertext without the key, which is the same attack as Vigenere with only 10 letters to try for each column
+// instead of 26. Keys come back as digits. Takes WithAlphabet, WithLanguage and WithTableau
+func CrackGronsfeld(ciphertext string, opts ...Option) ([]Candidate, error) {
+    o := getOptions(opts)
+    if o.alphabet.Len() < 10 {return nil, errors.New("alphabet is too short for a Gronsfeld key")}
+
+    // The same key letters GronsfeldEncrypt would use for each digit, and the way back
+    var keys []int
+    var digits map[rune]rune = make(map[rune]rune)
+    for d := 0; d < 10; d++ {
+        k := modulo(o.tableau.digitKey(d), o.alphabet.Len())
+        keys = append(keys, k)
+        digits[o.alphabet.Rune(k)] = rune('0' + d)
+    }
+
+    candidates, err := crackTableau(ciphertext, keys, o)
+    if err != nil {return nil, err}
+    for i := range candidates {
+        var key []rune
+        for _, cur := range candidates[i].Key {
+            key = append(key, digits[cur])
+        }
+        candidates[i].Key = string(key)
+    }
+    return candidates, nil
+}
+
+/* Single letter frequencies are enough to break a Caesar cipher, where there's only one number to find, but a general substitution
+cipher has 26! (about 4 * 10^26) keys, and knowing that E is the most common letter isn't going to narrow that down. What does is
+context: Q is nearly always followed by U, TH is everywhere, and JX never happens. Quadgrams (runs of 4 letters) capture enough of
+that context to tell real English apart from almost-English, so solvers that have to search through keys score with them instead
+
+The quadgrams come from the language model, along with the single letter frequencies some of the solvers start out from
+*/
+
+// A language model in the shape the solvers want it, so nothing has to go through strings in the middle of a search
+type quadgramModel struct {
+	// Log-probability of every quadgram, indexed by its letters as a base 26 number
+	scores []float64
+	// How often each letter turns up, as a fraction of all letters
+	freqs [ROMANWIDTH]float64
+	// The letters from most to least common
+	order []int
+}
+
+func newQuadgramModel(lang *language.Model) *quadgramModel {
+    var model *quadgramModel = &quadgramModel{}
+    model.scores, _ = lang.Table(4)
+
+    freqs, _ := lang.Frequencies(1)
+    for letter, freq := range freqs {
+        ind, _ := RomanAlphabet.Index([]rune(letter)[0])
+        model.freqs[ind] = freq
+    }
+    for _, letter := range lang.Order() {
+        ind, _ := RomanAlphabet.Index(letter)
+        model.order = append(model.order, ind)
+    }
+
+    return model
+}
+
+// Score text given as letter indices (0 = A). Texts shorter than 4 letters score 0
+func (m *quadgramModel) score(text []int) float64 {
+    var score float64
+    for i := 0; i + 4 <= len(text); i++ {
+        score += m.scores[((text[i]*ROMANWIDTH + text[i + 1])*ROMANWIDTH + text[i + 2])*ROMANWIDTH + text[i + 3]]
+    }
+    return score
+}
+
+// Turn A-Z text into letter indices, dropping anything else
+func letterIndices(text string) []int {
+    var res []int = make([]int, 0, len(text))
+    for _, cur := range text {
+        cur, _ = RomanAlphabet.Normalize(cur)
+        if ind, exists := RomanAlphabet.Index(cur); exists {res = append(res, ind)}
+    }
+    return res
+}
+
+/* The substitution solver is a hill climber. It starts from the key frequency analysis would guess (the most common ciphertext
+letter is E, the next is T, and so on), then keeps swapping letters in the key, holding on to any swap that makes the plaintext score
+better. Random swaps get most of the way quickly, and then every pair is tried in turn until no swap helps any more, which puts it
+at the top of a hill
+
+That hill isn't always the highest one, so the whole thing starts over a number of times (see WithRestarts), alternating between a
+shuffled key and a few swaps away from the best key so far, and the best key from any of the climbs wins. The winner gets one last
+pass that also tries rotating three letters at a time, which catches two rare letters that are each in the other's place. With a
+couple hundred letters of ciphertext the right key is nearly always found, give or take a rare letter or two that the quadgrams
+can't tell apart
+*/
+
+// The ciphertext letters from most to least common, with letters that don't appear at all at the end in alphabetical order
+func frequencyOrder(text []int) []int {
+    var counts [ROMANWIDTH]int
+    for _, cur := range text {
+        counts[cur]++
+    }
+
+    var order []int = make([]int, ROMANWIDTH)
+    for i := range order {
+        order[i] = i
+    }
+    slices.SortStableFunc(order, func(a, b int) int {return counts[b] - counts[a]})
+    return order
+}
+
+// Climb from key (ciphertext letter index -> plaintext letter index) by swapping random pairs of letters, until there's been
+// nothing but worse swaps for a good long while. key is changed in place
+func climbSubstitution(text []int, key []int, model *quadgramModel, r *rand.Rand) float64 {
+    var plain []int = make([]int, len(text))
+    decode := func() float64 {
+        for i, cur := range text {
+            plain[i] = key[cur]
+        }
+        return model.score(plain)
+    }
+
+    best := decode()
+    for failures := 0; failures < 2000; failures++ {
+        a, b, c := r.IntN(ROMANWIDTH), r.IntN(ROMANWIDTH), r.IntN(ROMANWIDTH)
+        if a == b || b == c || a == c {continue}
+
+        // Mostly swap two letters, but sometimes rotate three. Fixing two rare letters at once can take a rotation, since either
+        // swap on its own makes things worse
+        rotate := r.IntN(4) == 0
+        if rotate {
+            key[a], key[b], key[c] = key[b], key[c], key[a]
+        } else {
+            key[a], key[b] = key[b], key[a]
+        }
+
+        // Ties are kept too, so the climb can wander across flat ground (swapping letters that never show up in the text, say)
+        // without counting it as progress
+        if score := decode(); score >= best {
+            if score > best {failures = 0}
+            best = score
+        } else if rotate {
+            key[a], key[b], key[c] = key[c], key[a], key[b]
+        } else {
+            key[a], key[b] = key[b], key[a]
+        }
+    }
+
+    return best
+}
+
+// Finish off a climb by trying every swap (and every rotation of three letters, if rotate is set) over and over until none of them
+// help. The rotations are too slow to try after every climb, but cheap enough to try once on the best key
+func polishSubstitution(text []int, key []int, model *quadgramModel, rotate bool) float64 {
+    var plain []int = make([]int, len(text))
+    decode := func() float64 {
+        for i, cur := range text {
+            plain[i] = key[cur]
+        }
+        return model.score(plain)
+    }
+
+    best := decode()
+    for improved := true; improved; {
+        improved = false
+        for a := 0; a < ROMANWIDTH; a++ {
+            for b := a + 1; b < ROMANWIDTH; b++ {
+                key[a], key[b] = key[b], key[a]
+                if score := decode(); score > best {
+                    best, improved = score, true
+                    continue
+                }
+                key[a], key[b] = key[b], key[a]
+                if !rotate {continue}
+
+                for c := 0; c < ROMANWIDTH; c++ {
+                    if c == a || c == b {continue}
+                    key[a], key[b], key[c] = key[b], key[c], key[a]
+                    if score := decode(); score > best {
+                        best, improved = score, true
+                        break
+                    }
+                    key[a], key[b], key[c] = key[c], key[a], key[b]
+                }
+            }
+        }
+    }
+
+    return best
+}
+
+// Break a simple substitution cipher (Keyphrase, MVPC, or any other key that swaps one letter for another) without the key. Returns
+// the plaintext and the key that decrypts the ciphertext, mapping each ciphertext letter to its plaintext letter the same way
+// keymapProcess expects. Takes WithRestarts (40 by default), WithSeed and WithLanguage, and PreserveFormat keeps the punctuation in
+// the plaintext
+func CrackSubstitution(ciphertext string, opts ...Option) (string, map[rune]rune, error) {
+    o := getOptions(opts)
+    text := letterIndices(ciphertext)
+    if len(text) < 4 {return "", nil, errors.New("ciphertext is too short to crack")}
+    if o.restarts <= 0 {o.restarts = 40}
+    r := o.rng()
+    model := newQuadgramModel(o.language)
+
+    var key []int = make([]int, ROMANWIDTH)
+    for i, cur := range frequencyOrder(text) {
+        key[cur] = model.order[i]
+    }
+
+    var bestkey []int = slices.Clone(key)
+    climbSubstitution(text, bestkey, model, r)
+    var best float64 = polishSubstitution(text, bestkey, model, false)
+    for i := 1; i < o.restarts; i++ {
+        // Every other climb starts from a few swaps away from the best key so far, since the right key is usually close by
+        if i % 2 == 0 {
+            r.Shuffle(len(key), func(a, b int) {key[a], key[b] = key[b], key[a]})
+        } else {
+            copy(key, bestkey)
+            for j := 0; j < 4; j++ {
+                a, b := r.IntN(ROMANWIDTH), r.IntN(ROMANWIDTH)
+                key[a], key[b] = key[b], key[a]
+            }
+        }
+        climbSubstitution(text, key, model, r)
+        if score := polishSubstitution(text, key, model, false); score > best {
+            best = score
+            copy(bestkey, key)
+        }
+    }
+
+    polishSubstitution(text, bestkey, model, true)
+
+    var res map[rune]rune = make(map[rune]rune, ROMANWIDTH)
+    for c, p := range bestkey {
+        res[RomanAlphabet.Rune(c)] = RomanAlphabet.Rune(p)
+    }
+
+    plaintext, err := mvpcProcess(ciphertext, res, options{alphabet: RomanAlphabet, preserve: o.preserve})
+    return plaintext, res, err
+}
+
+/* Homophonic substitution hides the letter frequencies by giving common letters lots of symbols, but it doesn't do anything about
+which letters come next to each other. "THE" is still the most common trigram; it's just spelled a dozen different ways. So the
+attack is the same as for simple substitution, except the key is a letter for every symbol rather than a rearrangement of the
+alphabet, and there are a lot more symbols than letters. That's the same problem the Zodiac-340 solvers had to deal with, and like
+them this one uses simulated annealing rather than hill climbing
+
+Simulated annealing is a hill climber that's allowed to go downhill. A change that makes the score worse by d is still kept with a
+chance of e^(-d/T), where the temperature T starts high and drops to nothing by the end of the run. Early on it wanders all over the
+place and doesn't get stuck on the first hill it finds; by the end it's an ordinary hill climber. With this many symbols the extra
+wandering matters, since there are far more hills to get stuck on
+
+Symbol frequencies still tell you something. The first key hands out the most common symbols to whichever letters are the furthest
+below their share of English, which is close to how the key was made in the first place (see HomophonicEncrypt)
+*/
+
+// How much the letter counts drifting away from the language counts against a key, next to the quadgrams
+const HOMOPHONICDRIFT float64 = 5
+
+// Score of the quadgram starting at text[i]
+func (m *quadgramModel) at(text []int, i int) float64 {
+    return m.scores[((text[i]*ROMANWIDTH + text[i + 1])*ROMANWIDTH + text[i + 2])*ROMANWIDTH + text[i + 3]]
+}
+
+// Hand out symbols (most common first) to the letter that has the fewest of its share so far
+func homophonicKey(counts []int, total int, model *quadgramModel) []int {
+    var order []int = make([]int, len(counts))
+    for i := range order {
+        order[i] = i
+    }
+    slices.SortStableFunc(order, func(a, b int) int {return counts[b] - counts[a]})
+
+    var key []int = make([]int, len(counts))
+    var assigned [ROMANWIDTH]float64
+    for _, symbol := range order {
+        var best int
+        var bestdeficit float64 = math.Inf(-1)
+        for letter := 0; letter < ROMANWIDTH; letter++ {
+            deficit := model.freqs[letter] * float64(total) - assigned[letter]
+            if deficit > bestdeficit {best, bestdeficit = letter, deficit}
+        }
+        key[symbol] = best
+        assigned[best] += float64(counts[symbol])
+    }
+
+    return key
+}
+
+// One run of simulated annealing over key (symbol -> letter index), changed in place. Returns the score of the final key
+func annealHomophonic(text []int, positions [][]int, counts []int, key []int, model *quadgramModel, iterations int, r *rand.Rand) float64 {
+    var plain []int = make([]int, len(text))
+    for i, symbol := range text {
+        plain[i] = key[symbol]
+    }
+    // The quadgrams alone would happily turn everything into "THERESTHESE", since nothing stops every symbol from being E, T or H.
+    // So the letter counts are held close to English too, by taking off how far they are from it (as the total of n*log(n/expected))
+    var letters [ROMANWIDTH]int
+    var expected [ROMANWIDTH]float64
+    for _, cur := range plain {
+        letters[cur]++
+    }
+    for i := range expected {
+        expected[i] = max(model.freqs[i], 1e-6) * float64(len(plain))
+    }
+    drift := func(letter, n int) float64 {
+        if n <= 0 {return 0}
+        return HOMOPHONICDRIFT * float64(n) * math.Log(float64(n) / expected[letter])
+    }
+
+    score := model.score(plain)
+    for i, n := range letters {
+        score -= drift(i, n)
+    }
+    bestscore := score
+    var bestkey []int = slices.Clone(key)
+
+    // Only the quadgrams that cover a symbol's positions change when its letter does. Every quadgram that needs rescoring gets
+    // stamped with the symbol as it's collected, so one that covers the symbol twice isn't counted twice, and the stamps are wiped
+    // again before the next symbol
+    var stamps []int = make([]int, len(text))
+    var starts []int
+    delta := func(symbol int) []int {
+        starts = starts[:0]
+        for _, pos := range positions[symbol] {
+            for i := max(pos - 3, 0); i <= pos && i + 4 <= len(text); i++ {
+                if stamps[i] == -symbol - 1 {continue}
+                stamps[i] = -symbol - 1
+                starts = append(starts, i)
+            }
+        }
+        for _, i := range starts {
+            stamps[i] = 0
+        }
+        return starts
+    }
+    setletter := func(symbol, letter int) {
+        for _, pos := range positions[symbol] {
+            plain[pos] = letter
+        }
+    }
+
+    // A symbol turns up a handful of times and each of those is in four quadgrams, so changing its letter moves the score by tens.
+    // Starting the temperature around there means early on almost anything goes
+    const STARTTEMP float64 = 20
+    for i := 0; i < iterations; i++ {
+        temp := STARTTEMP * float64(iterations - i) / float64(iterations)
+        symbol := r.IntN(len(key))
+        letter := r.IntN(ROMANWIDTH)
+        if letter == key[symbol] {continue}
+
+        var before, after float64
+        for _, start := range delta(symbol) {
+            before += model.at(plain, start)
+        }
+        setletter(symbol, letter)
+        for _, start := range starts {
+            after += model.at(plain, start)
+        }
+        n, old := counts[symbol], key[symbol]
+        before -= drift(old, letters[old]) + drift(letter, letters[letter])
+        after -= drift(old, letters[old] - n) + drift(letter, letters[letter] + n)
+
+        if d := after - before; d >= 0 || r.Float64() < math.Exp(d / temp) {
+            key[symbol] = letter
+            letters[old] -= n
+            letters[letter] += n
+            score += d
+            if score > bestscore {
+                bestscore = score
+                copy(bestkey, key)
+            }
+        } else {
+            setletter(symbol, key[symbol])
+        }
+    }
+
+    copy(key, bestkey)
+    return bestscore
+}
+
+// Break a homophonic substitution cipher without the key. The ciphertext is symbols separated by spaces, the way
+// HomophonicEncrypt writes it. Returns the plaintext and a key that works with HomophonicDecrypt
+//
+// HomophonicEncrypt will encrypt anything, but the solver only knows about the letters A-Z, so it works best when the plaintext
+// was stripped of everything else before it was encrypted (spaces and punctuation get symbols too, and come back as letters). A few
+// thousand symbols is usually enough to read the plaintext, give or take a few rare letters; much less than that and there are so
+// many symbols that plenty of keys look more English than the real one. Takes WithIterations (enough for every letter to be tried
+// on every symbol 300 times by default), WithRestarts (10 by default), WithSeed and WithLanguage
+func CrackHomophonic(ciphertext string, opts ...Option) (string, map[string]rune, error) {
+    o := getOptions(opts)
+    symbols := strings.Fields(ciphertext)
+    if len(symbols) < 4 {return "", nil, errors.New("ciphertext is too short to crack")}
+    // A run gets stuck about half the time, so it takes 10 of them before a stuck answer is rare enough to ignore
+    if o.restarts <= 0 {o.restarts = 10}
+    r := o.rng()
+    model := newQuadgramModel(o.language)
+
+    // Number the symbols in the order they first show up
+    var ids map[string]int = make(map[string]int)
+    var names []string
+    var text []int = make([]int, len(symbols))
+    for i, symbol := range symbols {
+        id, exists := ids[symbol]
+        if !exists {
+            id = len(names)
+            ids[symbol] = id
+            names = append(names, symbol)
+        }
+        text[i] = id
+    }
+
+    var positions [][]int = make([][]int, len(names))
+    var counts []int = make([]int, len(names))
+    for i, id := range text {
+        positions[id] = append(positions[id], i)
+        counts[id]++
+    }
+
+    // Each run gets enough steps to try every letter for every symbol a few hundred times
+    if o.iterations <= 0 {o.iterations = len(names) * ROMANWIDTH * 300}
+    start := homophonicKey(counts, len(text), model)
+    var bestkey []int = slices.Clone(start)
+    var key []int = make([]int, len(start))
+    var best float64 = annealHomophonic(text, positions, counts, bestkey, model, o.iterations, r)
+    for i := 1; i < o.restarts; i++ {
+        // Starting every run from the same key would leave them all heading for the same hills, so after the first one an eighth
+        // of the symbols get a random letter instead
+        copy(key, start)
+        for symbol := range key {
+            if r.IntN(8) == 0 {key[symbol] = r.IntN(ROMANWIDTH)}
+        }
+        if score := annealHomophonic(text, positions, counts, key, model, o.iterations, r); score > best {
+            best = score
+            copy(bestkey, key)
+        }
+    }
+
+    var res map[string]rune = make(map[string]rune, len(names))
+    var plaintext []rune = make([]rune, len(text))
+    for id, name := range names {
+        res[name] = RomanAlphabet.Rune(bestkey[id])
+    }
+    for i, id := range text {
+        plaintext[i] = res[names[id]]
+    }
+
+    return string(plaintext), res, nil
+}
+
+
+/* A Playfair square can be arranged 25! ways, which is as many keys as a simple substitution, but the substitution solver's trick
+of swapping two letters and keeping the swap if it helps doesn't work nearly as well here. Moving one letter in the square changes
+how every pair in its row and column gets deciphered, so the score jumps around a lot more, and a hill climber gets stuck on the
+first little hill it finds
+
+Simulated annealing gets around that by sometimes taking a step that makes things worse. How likely that is depends on a
+temperature, which starts high (so the search wanders all over the place) and drops steadily to nothing (so by the end it only goes
+uphill). Most steps swap two letters, but now and then a whole pair of rows or columns gets swapped, or the square gets flipped over,
+since a square that's right apart from its rows being in the wrong order is otherwise a long way from the right one
+
+There isn't one right square. Moving every row down one (or every column across) gives a different square that enciphers exactly
+the same way, so the square that comes back might not be the one that was used, but it'll decipher the ciphertext just as well
+*/
+
+// How hot the annealing starts, per letter of ciphertext. Anywhere from about 0.1 to 0.2 finds the right square just as often
+const PLAYFAIRTEMP float64 = 0.13
+
+// Decipher text (letter indices, in pairs) with square (the letter in each place of the 5×5 square), where places is the place of
+// each letter in the square
+func playfairDecipher(text []int, square []int, places []int, plain []int) {
+    for i := 0; i + 1 < len(text); i += 2 {
+        a, b := places[text[i]], places[text[i + 1]]
+        rowa, cola, rowb, colb := a / 5, a % 5, b / 5, b % 5
+        switch {
+        case rowa == rowb:
+            plain[i], plain[i + 1] = square[rowa * 5 + (cola + 4) % 5], square[rowb * 5 + (colb + 4) % 5]
+        case cola == colb:
+            plain[i], plain[i + 1] = square[(rowa + 4) % 5 * 5 + cola], square[(rowb + 4) % 5 * 5 + colb]
+        default:
+            plain[i], plain[i + 1] = square[rowa * 5 + colb], square[rowb * 5 + cola]
+        }
+    }
+}
+
+// Change square in one of the ways the annealing tries
+func changeSquare(square []int, r *rand.Rand) {
+    switch choice := r.IntN(50); {
+    case choice < 45:
+        a, b := r.IntN(25), r.IntN(25)
+        square[a], square[b] = square[b], square[a]
+    case choice < 46:
+        a, b := r.IntN(5), r.IntN(5)
+        for col := range 5 {
+            square[a * 5 + col], square[b * 5 + col] = square[b * 5 + col], square[a * 5 + col]
+        }
+    case choice < 47:
+        a, b := r.IntN(5), r.IntN(5)
+        for row := range 5 {
+            square[row * 5 + a], square[row * 5 + b] = square[row * 5 + b], square[row * 5 + a]
+        }
+    case choice < 48:
+        // Upside down
+        for row := range 2 {
+            for col := range 5 {
+                square[row * 5 + col], square[(4 - row) * 5 + col] = square[(4 - row) * 5 + col], square[row * 5 + col]
+            }
+        }
+    case choice < 49:
+        // Left to right
+        for row := range 5 {
+            for col := range 2 {
+                square[row * 5 + col], square[row * 5 + 4 - col] = square[row * 5 + 4 - col], square[row * 5 + col]
+            }
+        }
+    default:
+        // Over the diagonal
+        for row := range 5 {
+            for col := row + 1; col < 5; col++ {
+                square[row * 5 + col], square[col * 5 + row] = square[col * 5 + row], square[row * 5 + col]
+            }
+        }
+    }
+}
+
+// One run of simulated annealing over square, changed in place. Returns the score of the final square
+func annealPlayfair(text []int, square []int, model *quadgramModel, iterations int, r *rand.Rand) float64 {
+    var places []int = make([]int, ROMANWIDTH)
+    var plain []int = make([]int, len(text))
+    score := func(square []int) float64 {
+        for i, cur := range square {
+            places[cur] = i
+        }
+        playfairDecipher(text, square, places, plain)
+        return model.score(plain)
+    }
+
+    current := score(square)
+    best := current
+    var bestsquare, candidate []int = slices.Clone(square), make([]int, len(square))
+    starttemp := PLAYFAIRTEMP * float64(len(text))
+
+    for i := 0; i < iterations; i++ {
+        temp := starttemp * float64(iterations - i) / float64(iterations)
+        copy(candidate, square)
+        changeSquare(candidate, r)
+
+        next := score(candidate)
+        if d := next - current; d >= 0 || r.Float64() < math.Exp(d / temp) {
+            copy(square, candidate)
+            current = next
+            if current > best {
+                best = current
+                copy(bestsquare, square)
+            }
+        }
+    }
+
+    copy(square, bestsquare)
+    return best
+}
+
+// Break a Playfair ciphertext without the key. Returns the plaintext (fillers and all) and the square, written out row by row so
+// it can go straight back into PlayfairDecrypt as the keyphrase. Only works on the usual 5×5 square with J written as I, and needs
+// a good 100 pairs of letters to be reliable. Takes WithIterations (how many squares each run tries, a million by default),
+// WithRestarts (4 by default), WithSeed and WithLanguage. With 100 pairs, each run finds the right square a bit more than half the
+// time, and giving it more iterations than the default doesn't help that much, so it's better to add restarts instead
+func CrackPlayfair(ciphertext string, opts ...Option) (string, string, error) {
+    o := getOptions(opts)
+    if o.restarts <= 0 {o.restarts = 4}
+    if o.iterations <= 0 {o.iterations = 1000000}
+    stripped, err := PlayfairAlphabet.Strip(ciphertext)
+    if err != nil {return "", "", err}
+    if len(stripped) < 4 || len(stripped) % 2 != 0 {return "", "", fmt.Errorf("ciphertext has %v letters, which is too short or won't split into pairs", len(stripped))}
+    text := letterIndices(stripped)
+    r := o.rng()
+    model := newQuadgramModel(o.language)
+
+    var square []int = letterIndices(PlayfairAlphabet.String())
+    var bestsquare []int
+    var best float64 = math.Inf(-1)
+    for range o.restarts {
+        r.Shuffle(len(square), func(a, b int) {square[a], square[b] = square[b], square[a]})
+        if score := annealPlayfair(text, square, model, o.iterations, r); score > best {
+            best = score
+            bestsquare = slices.Clone(square)
+        }
+    }
+
+    var key []rune = make([]rune, len(bestsquare))
+    for i, cur := range bestsquare {
+        key[i] = RomanAlphabet.Rune(cur)
+    }
+    plaintext, err := PlayfairDecrypt(stripped, string(key))
+    return plaintext, string(key), err
+}
@@ -0,0 +1,380 @@
+package ciphers
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/realconebob/ciphers/language"
+)
+
+// Some English to encipher and break again, long enough that the statistics have something to work with
+func sampleText(t *testing.T, letters int) string {
+	t.Helper()
+	keytext, err := os.ReadFile("testdata/declaration.txt")
+	if err != nil {
+		t.Fatalf("Could not read sample text: %v", err)
+	}
+	stripped, _ := stripnonalpha(string(keytext))
+	return stripped[:letters]
+}
+
+// How many letters of a and b don't match
+func wrongLetters(a, b string) int {
+	var wrong int = max(len(a), len(b)) - min(len(a), len(b))
+	for i := 0; i < min(len(a), len(b)); i++ {
+		if a[i] != b[i] {
+			wrong++
+		}
+	}
+	return wrong
+}
+
+func TestIndexOfCoincidence(t *testing.T) {
+	res1, err := IndexOfCoincidence(sampleText(t, 2000))
+	if relativeError(res1, language.English.IndexOfCoincidence()) > 0.1 || err != nil {
+		t.Errorf("English came out with an unusual index of coincidence: %v (%v)", res1, err)
+	}
+
+	ciphertext, _ := VigenereEncrypt(sampleText(t, 2000), "KASISKI")
+	res2, err := IndexOfCoincidence(ciphertext)
+	if res2 >= 0.05 || err != nil {
+		t.Errorf("Vigenere ciphertext came out with an index of coincidence too close to English: %v (%v)", res2, err)
+	}
+}
+
+func TestCrackAffine(t *testing.T) {
+	plaintext := sampleText(t, 60)
+
+	for _, key := range []AffineKey{{5, 8}, {25, 25}, {1, 3}, {19, 0}} {
+		ciphertext, _ := AffineEncrypt(plaintext, key)
+		res, err := CrackAffine(ciphertext)
+		if len(res) != 312 || err != nil {
+			t.Fatalf("Got incorrect candidates from cracking affine: %v (%v)", len(res), err)
+		}
+		if res[0].Key != fmt.Sprintf("%v,%v", key.Multiplier, key.Offset) || res[0].Plaintext != plaintext {
+			t.Errorf("Got incorrect best candidate for affine key %+v: %+v", key, res[0])
+		}
+	}
+
+	const PLAINTEXT string = "Veni, vidi, vici! (Or so Caesar wrote in 47 BC.)"
+	ciphertext, _ := AffineEncrypt(PLAINTEXT, AffineKey{7, 2}, PreserveFormat())
+	res, err := CrackAffine(ciphertext, PreserveFormat())
+	if len(res) <= 0 || res[0].Key != "7,2" || res[0].Plaintext != PLAINTEXT || err != nil {
+		t.Errorf("Got incorrect best candidate from cracking formatted affine: %+v (%v)", res, err)
+	}
+	if _, err := CrackAffine("!"); err == nil {
+		t.Errorf("Cracked a ciphertext with no letters in it")
+	}
+}
+
+func TestCrackHill(t *testing.T) {
+	plaintext := sampleText(t, 60)
+
+	for _, keyword := range []string{"HILL", "GYBNQKURP", "MATRIXMULTIPLICATIONWORKS"} {
+		key, _ := HillMatrix(keyword)
+		ciphertext, err := HillEncrypt(plaintext, key)
+		if err != nil {
+			t.Fatalf("Could not encrypt with key %v: %v", keyword, err)
+		}
+
+		res, err := CrackHill(plaintext, ciphertext, len(key))
+		if !slices.EqualFunc(res, key, slices.Equal) || err != nil {
+			t.Errorf("Got incorrect key for %v: %v (%v)", keyword, res, err)
+		}
+	}
+
+	key, _ := HillMatrix("HILL")
+	ciphertext, _ := HillEncrypt("AAAAAAAA", key)
+	if _, err := CrackHill("AAAAAAAA", ciphertext, 2); err == nil {
+		t.Errorf("Found a key from blocks that are all the same")
+	}
+	if _, err := CrackHill(plaintext, plaintext[1:] + "A", 2); err == nil {
+		t.Errorf("Found a key for plaintext and ciphertext that don't go together")
+	}
+	if _, err := CrackHill("ABC", "DEF", 2); err == nil {
+		t.Errorf("Found a key from less than 2 blocks")
+	}
+}
+
+func TestCrackVigenere(t *testing.T) {
+	tests := []struct {
+		letters int
+		key string
+	}{
+		{300, "LEMON"},
+		{500, "BABBAGE"},
+		{400, "ANDYETEMANCIPATED"},
+		{200, "Z"},
+	}
+
+	for _, test := range tests {
+		plaintext := sampleText(t, test.letters)
+		ciphertext, _ := VigenereEncrypt(plaintext, test.key)
+
+		res, err := CrackVigenere(ciphertext)
+		if len(res) <= 0 || err != nil {
+			t.Fatalf("Could not crack Vigenere with key %v: %v", test.key, err)
+		}
+		if res[0].Key != test.key || res[0].Plaintext != plaintext {
+			t.Errorf("Got incorrect best candidate for key %v: %+v", test.key, res[0])
+		}
+		for i := 1; i < len(res); i++ {
+			if res[i].Score > res[i - 1].Score {
+				t.Errorf("Candidates are out of order: %+v before %+v", res[i - 1], res[i])
+			}
+		}
+	}
+
+	if _, err := CrackVigenere("!"); err == nil {
+		t.Errorf("Cracked a ciphertext with no letters in it")
+	}
+}
+
+func TestCrackBeaufort(t *testing.T) {
+	plaintext := sampleText(t, 300)
+
+	// The same attack breaks both Beaufort tableaus, as long as it's told which one
+	ciphertext1, _ := BeaufortEncrypt(plaintext, "FORTIFICATION")
+	res1, err := CrackVigenere(ciphertext1, WithTableau(BeaufortTableau))
+	if len(res1) <= 0 || res1[0].Key != "FORTIFICATION" || res1[0].Plaintext != plaintext || err != nil {
+		t.Errorf("Got incorrect best candidate for Beaufort: %+v (%v)", res1, err)
+	}
+
+	ciphertext2, _ := VariantBeaufortEncrypt(plaintext, "LEMON")
+	res2, err := CrackVigenere(ciphertext2, WithTableau(VariantBeaufortTableau))
+	if len(res2) <= 0 || res2[0].Key != "LEMON" || res2[0].Plaintext != plaintext || err != nil {
+		t.Errorf("Got incorrect best candidate for variant Beaufort: %+v (%v)", res2, err)
+	}
+}
+
+func TestCrackGronsfeld(t *testing.T) {
+	plaintext := sampleText(t, 200)
+	ciphertext, _ := GronsfeldEncrypt(plaintext, "314159")
+
+	res, err := CrackGronsfeld(ciphertext)
+	if len(res) <= 0 || res[0].Key != "314159" || res[0].Plaintext != plaintext || err != nil {
+		t.Errorf("Got incorrect best candidate for Gronsfeld: %+v (%v)", res, err)
+	}
+	if _, err := CrackGronsfeld("!"); err == nil {
+		t.Errorf("Cracked a ciphertext with no letters in it")
+	}
+
+	// Other alphabets and tableaus have their digits on different key letters, and the cracker has to use the same ones
+	latin, _ := LatinAlphabet.Strip(plaintext)
+	for _, opts := range [][]Option{
+		{WithAlphabet(LatinAlphabet)},
+		{WithTableau(BeaufortTableau)},
+		{WithAlphabet(LatinAlphabet), WithTableau(VariantBeaufortTableau)},
+	} {
+		want := plaintext
+		if getOptions(opts).alphabet == LatinAlphabet {want = latin}
+		ciphertext, _ := GronsfeldEncrypt(plaintext, "2718", opts...)
+		res, err := CrackGronsfeld(ciphertext, opts...)
+		if len(res) <= 0 || res[0].Key != "2718" || res[0].Plaintext != want || err != nil {
+			t.Errorf("Got incorrect best candidate for Gronsfeld with %v options: %+v (%v)", len(opts), res, err)
+		}
+	}
+
+	// Vigenere too
+	ciphertext, _ = VigenereEncrypt(plaintext, "LVCRETIVS", WithAlphabet(LatinAlphabet))
+	res, err = CrackVigenere(ciphertext, WithAlphabet(LatinAlphabet))
+	if len(res) <= 0 || res[0].Key != "LVCRETIVS" || res[0].Plaintext != latin || err != nil {
+		t.Errorf("Got incorrect best candidate for Vigenere on the Latin alphabet: %+v (%v)", res, err)
+	}
+}
+
+func TestCrackROTX(t *testing.T) {
+	const PLAINTEXT string = "Veni, vidi, vici! (Or so Caesar wrote in 47 BC.)"
+
+	ciphertext, _ := CaesarEncrypt(PLAINTEXT)
+	res1, err := CrackROTX(ciphertext)
+	if len(res1) != ROMANWIDTH - 1 || err != nil {
+		t.Fatalf("Got incorrect candidates from cracking Caesar: %v (%v)", res1, err)
+	}
+	if res1[0].Key != "3" || res1[0].Plaintext != "VENIVIDIVICIORSOCAESARWROTEINBC" {
+		t.Errorf("Got incorrect best candidate from cracking Caesar: %+v", res1[0])
+	}
+	for i := 1; i < len(res1); i++ {
+		if res1[i].Score > res1[i - 1].Score {
+			t.Errorf("Candidates are out of order: %+v before %+v", res1[i - 1], res1[i])
+		}
+	}
+
+	for offset := rune(1); offset < rune(ROMANWIDTH); offset++ {
+		ciphertext, _ := ROTX(sampleText(t, 40), offset)
+		res2, err := CrackROTX(ciphertext)
+		if len(res2) <= 0 || res2[0].Key != fmt.Sprint(offset) || res2[0].Plaintext != sampleText(t, 40) || err != nil {
+			t.Errorf("Could not crack ROTX with offset %v: %+v (%v)", offset, res2, err)
+		}
+	}
+
+	ciphertext, _ = ROTX(PLAINTEXT, 13, PreserveFormat())
+	res3, err := CrackROTX(ciphertext, PreserveFormat())
+	if len(res3) <= 0 || res3[0].Key != "13" || res3[0].Plaintext != PLAINTEXT || err != nil {
+		t.Errorf("Got incorrect best candidate from cracking formatted ROT13: %+v (%v)", res3, err)
+	}
+
+	// A real Caesar cipher: Latin, over the Latin alphabet
+	latin, _ := LatinAlphabet.Strip("Caesar cum exercitu in Galliam venit et castra prope flumen posuit")
+	ciphertext, _ = CaesarEncrypt(latin, WithAlphabet(LatinAlphabet))
+	res4, err := CrackROTX(ciphertext, WithAlphabet(LatinAlphabet), WithLanguage(language.Latin))
+	if len(res4) != LatinAlphabet.Len() - 1 || res4[0].Key != "3" || res4[0].Plaintext != latin || err != nil {
+		t.Errorf("Got incorrect best candidate from cracking Latin Caesar: %+v (%v)", res4, err)
+	}
+
+	if _, err := CrackROTX("1234"); err == nil {
+		t.Errorf("Cracked a ciphertext with no letters in it")
+	}
+}
+
+func TestCrackRailfence(t *testing.T) {
+	plaintext := sampleText(t, 200)
+
+	for _, key := range [][2]int{{2, 0}, {3, 1}, {7, 5}, {12, 20}} {
+		ciphertext, _ := RailfenceEncryptN(plaintext, key[0], key[1])
+		res, err := CrackRailfence(ciphertext)
+		if len(res) <= 0 || res[0].Key != fmt.Sprintf("%v,%v", key[0], key[1]) || res[0].Plaintext != plaintext || err != nil {
+			t.Errorf("Could not crack %v rails with offset %v: %+v (%v)", key[0], key[1], res[:min(len(res), 1)], err)
+		}
+		for i := 1; i < len(res); i++ {
+			if res[i].Score > res[i - 1].Score {
+				t.Errorf("Candidates are out of order: %+v before %+v", res[i - 1], res[i])
+			}
+		}
+	}
+
+	// Short texts run out of different plaintexts long before they run out of keys
+	res, _ := CrackRailfence("TYERTSHPIOEITOLTTOHURARSNROTHSCEITYRSNRFHUEIGTOATPIOETI")
+	var seen map[string]bool = make(map[string]bool)
+	for _, cur := range res {
+		if seen[cur.Plaintext] {
+			t.Errorf("Got the same plaintext twice: %v", cur.Plaintext)
+		}
+		seen[cur.Plaintext] = true
+	}
+	if len(res) <= 0 || res[0].Key != "2,0" || res[0].Plaintext != "THYSECRETISTHYPRISONERIFTHOULETITGOTHOUARTAPRISONERTOIT" {
+		t.Errorf("Got incorrect best candidate from cracking the two rail fence: %+v", res[:min(len(res), 1)])
+	}
+
+	if _, err := CrackRailfence("AB"); err == nil {
+		t.Errorf("Cracked a ciphertext that's too short")
+	}
+}
+
+func TestCrackSubstitution(t *testing.T) {
+	// A couple hundred letters from a few different places in the sample text, under a few different keys
+	text := sampleText(t, 4000)
+	tests := []struct {
+		start int
+		keyphrase string
+	}{
+		{0, "JULIUS CAESAR"},
+		{1000, "MARY QUEEN OF SCOTS"},
+		{2200, "BABINGTON"},
+		{3500, "THE CODE BOOK"},
+	}
+
+	for _, test := range tests {
+		plaintext := text[test.start:test.start + 200]
+		ciphertext, _ := KeyphraseEncrypt(plaintext, test.keyphrase)
+
+		// Rare letters (B, V, X and so on) can come out swapped when they only show up once or twice, since the quadgrams can't
+		// always tell them apart. Anything more than 3% wrong is a real miss though
+		res, key, err := CrackSubstitution(ciphertext, WithSeed(1))
+		if wrong := wrongLetters(res, plaintext); wrong > len(plaintext) * 3 / 100 || err != nil {
+			t.Errorf("Could not crack keyphrase %v, %v letters wrong: %v (%v)", test.keyphrase, wrong, res, err)
+		}
+		// The key has to work with keymapProcess, the same way an MVPC key does
+		res2, err := keymapProcess(ciphertext, key)
+		if res2 != res || err != nil {
+			t.Errorf("Recovered key doesn't decrypt with keymapProcess: %v (%v)", res2, err)
+		}
+	}
+
+	plaintext := "When in the Course of human events, it becomes necessary for one people to dissolve the political bands which have " +
+		"connected them with another, and to assume among the powers of the earth, the separate and equal station"
+	ciphertext, _ := KeyphraseEncrypt(plaintext, "SIMON SINGH", PreserveFormat())
+	res3, _, err := CrackSubstitution(ciphertext, PreserveFormat(), WithSeed(2))
+	if res3 != plaintext || err != nil {
+		t.Errorf("Could not crack formatted keyphrase: %v (%v)", res3, err)
+	}
+
+	// The same seed has to give the same answer
+	res4, _, _ := CrackSubstitution(ciphertext, WithSeed(3), WithRestarts(3))
+	res5, _, _ := CrackSubstitution(ciphertext, WithSeed(3), WithRestarts(3))
+	if res4 != res5 {
+		t.Errorf("Same seed gave different answers: %v vs %v", res4, res5)
+	}
+
+	if _, _, err := CrackSubstitution("ABC"); err == nil {
+		t.Errorf("Cracked a ciphertext that's too short")
+	}
+}
+
+func TestCrackHomophonic(t *testing.T) {
+	// Homophonic keys are random every time, so this only asks for something readable: a few rare letters can come out wrong
+	// when the real key and a slightly wrong one are about as English as each other
+	plaintext := sampleText(t, 1000)
+	ciphertext, _, err := HomophonicEncrypt(plaintext, 1000)
+	if err != nil {
+		t.Fatalf("Could not encrypt sample text: %v", err)
+	}
+
+	res1, key, err := CrackHomophonic(ciphertext, WithSeed(1))
+	if wrong := wrongLetters(res1, plaintext); wrong > len(plaintext) / 10 || err != nil {
+		t.Errorf("Could not crack homophonic cipher, %v letters wrong: %v (%v)", wrong, res1, err)
+	}
+	// The key has to work with HomophonicDecrypt
+	res2, err := HomophonicDecrypt(ciphertext, key)
+	if res2 != res1 || err != nil {
+		t.Errorf("Recovered key doesn't decrypt with HomophonicDecrypt: %v (%v)", res2, err)
+	}
+
+	// The same seed has to give the same answer
+	short := strings.Join(strings.Fields(ciphertext)[:100], " ")
+	res3, _, _ := CrackHomophonic(short, WithSeed(2), WithRestarts(2), WithIterations(20000))
+	res4, _, _ := CrackHomophonic(short, WithSeed(2), WithRestarts(2), WithIterations(20000))
+	if res3 != res4 {
+		t.Errorf("Same seed gave different answers: %v vs %v", res3, res4)
+	}
+
+	if _, _, err := CrackHomophonic("12 34 56"); err == nil {
+		t.Errorf("Cracked a ciphertext that's too short")
+	}
+}
+
+func TestCrackPlayfair(t *testing.T) {
+	// 100 pairs of letters, which is about as short as CrackPlayfair can reliably go
+	plaintext := sampleText(t, 200)
+	ciphertext, _ := PlayfairEncrypt(plaintext, "CHARLES WHEATSTONE")
+	want, _ := PlayfairDecrypt(ciphertext, "CHARLES WHEATSTONE")
+
+	// The X fillers don't look much like English, so a square with X and Y (or some other rare letter) swapped can score as well
+	// as the real one. That costs a few letters, but anything more than 5% wrong is a real miss
+	res1, square, err := CrackPlayfair(ciphertext, WithSeed(1))
+	if wrong := wrongLetters(res1, want); wrong > len(want) / 20 || err != nil {
+		t.Errorf("Could not crack playfair, %v letters wrong: %v (%v)", wrong, res1, err)
+	}
+	// The square has to work as a keyphrase for PlayfairDecrypt
+	res2, err := PlayfairDecrypt(ciphertext, square)
+	if res2 != res1 || err != nil {
+		t.Errorf("Recovered square doesn't decrypt with PlayfairDecrypt: %v (%v)", res2, err)
+	}
+
+	// The same seed has to give the same answer
+	res3, _, _ := CrackPlayfair(ciphertext, WithSeed(2), WithRestarts(1), WithIterations(20000))
+	res4, _, _ := CrackPlayfair(ciphertext, WithSeed(2), WithRestarts(1), WithIterations(20000))
+	if res3 != res4 {
+		t.Errorf("Same seed gave different answers: %v vs %v", res3, res4)
+	}
+
+	if _, _, err := CrackPlayfair("ABC"); err == nil {
+		t.Errorf("Cracked a ciphertext that won't split into pairs")
+	}
+	if _, _, err := CrackPlayfair("AB"); err == nil {
+		t.Errorf("Cracked a ciphertext that's too short")
+	}
+}
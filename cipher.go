@@ -0,0 +1,357 @@
+/** CIPHER INTERFACE
+
+Every cipher in this package started life as its own pair of functions, and because of that they all take and return slightly
+different things. RailfenceEncrypt only wants text, ROTX wants an offset, MVPCEncrypt hands back the key it made up, and so on. That's
+fine when you're calling one of them directly, but it gets annoying fast when you want to treat them all the same (running every
+cipher over the same text, picking one from a config file, etc.)
+
+The Cipher interface is the common ground: something with a name, a key, and a way to encrypt and decrypt text. The types below are
+thin adapters over the existing functions, so they behave exactly the same as calling the functions by hand. Adapters for ciphers
+that take options have an Options field, which is passed along to every function call (to pick a different alphabet, or to preserve the formatting of the text)
+*/
+
+package ciphers
+
+import (
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+type Cipher interface {
+	// Short, stable name of the cipher ("caesar", "vigenere", ...)
+	Name() string
+	// The key currently in use, written out as text. Keyless ciphers return an empty string
+	Key() string
+	Encrypt(plaintext string) (string, error)
+	Decrypt(ciphertext string) (string, error)
+}
+
+// Write out a rune -> rune key as space separated pairs ("AV BH CM ..."), sorted so the same key always gives the same string
+func formatRuneMap(key map[rune]rune) string {
+    var pairs []string = make([]string, 0, len(key))
+    for from, to := range key {
+        pairs = append(pairs, string(from) + string(to))
+    }
+    slices.Sort(pairs)
+
+    return strings.Join(pairs, " ")
+}
+
+// Write out a homophonic key as `symbol:'letter'` entries. The letters are quoted because homophonic plaintext isn't stripped, so
+// a symbol can just as easily stand for a space or a comma
+func formatSymbolMap(key map[string]rune) string {
+    var symbols []string = make([]string, 0, len(key))
+    for symbol := range key {
+        symbols = append(symbols, symbol)
+    }
+    slices.SortFunc(symbols, func(a, b string) int {
+        if len(a) != len(b) {return len(a) - len(b)}
+        return strings.Compare(a, b)
+    })
+
+    var entries []string = make([]string, 0, len(symbols))
+    for _, symbol := range symbols {
+        entries = append(entries, fmt.Sprintf("%s:%q", symbol, key[symbol]))
+    }
+
+    return strings.Join(entries, " ")
+}
+
+
+// If Rails is 0, this is the two rail version from the book, and has no key. Otherwise the key is written "RAILS,OFFSET"
+type RailfenceCipher struct {
+    Rails int
+    Offset int
+    Options []Option
+}
+
+func (c *RailfenceCipher) Name() string {return "railfence"}
+func (c *RailfenceCipher) Key() string {
+    if c.Rails == 0 {return ""}
+    return fmt.Sprintf("%v,%v", c.Rails, c.Offset)
+}
+func (c *RailfenceCipher) Encrypt(plaintext string) (string, error) {
+    if c.Rails == 0 {return RailfenceEncrypt(plaintext, c.Options...)}
+    return RailfenceEncryptN(plaintext, c.Rails, c.Offset, c.Options...)
+}
+func (c *RailfenceCipher) Decrypt(ciphertext string) (string, error) {
+    if c.Rails == 0 {return RailfenceDecrypt(ciphertext, c.Options...)}
+    return RailfenceDecryptN(ciphertext, c.Rails, c.Offset, c.Options...)
+}
+
+
+type ColumnarCipher struct {
+    Keyword string
+    Options []Option
+}
+
+func (c *ColumnarCipher) Name() string {return "columnar"}
+func (c *ColumnarCipher) Key() string {return c.Keyword}
+func (c *ColumnarCipher) Encrypt(plaintext string) (string, error) {return ColumnarEncrypt(plaintext, c.Keyword, c.Options...)}
+func (c *ColumnarCipher) Decrypt(ciphertext string) (string, error) {return ColumnarDecrypt(ciphertext, c.Keyword, c.Options...)}
+
+
+// The key is written "FIRST,SECOND"
+type DoubleColumnarCipher struct {
+    First, Second string
+    Options []Option
+}
+
+func (c *DoubleColumnarCipher) Name() string {return "doublecolumnar"}
+func (c *DoubleColumnarCipher) Key() string {return c.First + "," + c.Second}
+func (c *DoubleColumnarCipher) Encrypt(plaintext string) (string, error) {
+    return DoubleColumnarEncrypt(plaintext, c.First, c.Second, c.Options...)
+}
+func (c *DoubleColumnarCipher) Decrypt(ciphertext string) (string, error) {
+    return DoubleColumnarDecrypt(ciphertext, c.First, c.Second, c.Options...)
+}
+
+
+// If Pairs is nil, the first call to Encrypt generates a key and keeps it for later calls
+type MVPCCipher struct {
+    Pairs map[rune]rune
+    Options []Option
+}
+
+func (c *MVPCCipher) Name() string {return "mvpc"}
+func (c *MVPCCipher) Key() string {return formatRuneMap(c.Pairs)}
+func (c *MVPCCipher) Encrypt(plaintext string) (string, error) {
+    if c.Pairs == nil {
+        ciphertext, key, err := MVPCEncrypt(plaintext, c.Options...)
+        if err != nil {return "", err}
+        c.Pairs = key
+        return ciphertext, nil
+    }
+
+    return mvpcProcess(plaintext, c.Pairs, getOptions(c.Options))
+}
+func (c *MVPCCipher) Decrypt(ciphertext string) (string, error) {return MVPCDecrypt(ciphertext, c.Pairs, c.Options...)}
+
+
+type ROTXCipher struct {
+    Offset rune
+    Options []Option
+}
+
+func (c *ROTXCipher) Name() string {return "rotx"}
+func (c *ROTXCipher) Key() string {return fmt.Sprint(c.Offset)}
+func (c *ROTXCipher) Encrypt(plaintext string) (string, error) {return ROTX(plaintext, c.Offset, c.Options...)}
+func (c *ROTXCipher) Decrypt(ciphertext string) (string, error) {return ROTX(ciphertext, -c.Offset, c.Options...)}
+
+
+type CaesarCipher struct {
+    Options []Option
+}
+
+func (c *CaesarCipher) Name() string {return "caesar"}
+func (c *CaesarCipher) Key() string {return ""}
+func (c *CaesarCipher) Encrypt(plaintext string) (string, error) {return CaesarEncrypt(plaintext, c.Options...)}
+func (c *CaesarCipher) Decrypt(ciphertext string) (string, error) {return CaesarDecrypt(ciphertext, c.Options...)}
+
+
+type KeyphraseCipher struct {
+    Keyphrase string
+    Options []Option
+}
+
+func (c *KeyphraseCipher) Name() string {return "keyphrase"}
+func (c *KeyphraseCipher) Key() string {return c.Keyphrase}
+func (c *KeyphraseCipher) Encrypt(plaintext string) (string, error) {return KeyphraseEncrypt(plaintext, c.Keyphrase, c.Options...)}
+func (c *KeyphraseCipher) Decrypt(ciphertext string) (string, error) {return KeyphraseDecrypt(ciphertext, c.Keyphrase, c.Options...)}
+
+
+type AtbashCipher struct {
+    Options []Option
+}
+
+func (c *AtbashCipher) Name() string {return "atbash"}
+func (c *AtbashCipher) Key() string {return ""}
+func (c *AtbashCipher) Encrypt(plaintext string) (string, error) {return Atbash(plaintext, c.Options...)}
+func (c *AtbashCipher) Decrypt(ciphertext string) (string, error) {return Atbash(ciphertext, c.Options...)}
+
+
+// CaesarAffineKey and AtbashAffineKey make this do the same thing as CaesarCipher and AtbashCipher
+type AffineCipher struct {
+    AffineKey AffineKey
+    Options []Option
+}
+
+func (c *AffineCipher) Name() string {return "affine"}
+func (c *AffineCipher) Key() string {return fmt.Sprintf("%v,%v", c.AffineKey.Multiplier, c.AffineKey.Offset)}
+func (c *AffineCipher) Encrypt(plaintext string) (string, error) {return AffineEncrypt(plaintext, c.AffineKey, c.Options...)}
+func (c *AffineCipher) Decrypt(ciphertext string) (string, error) {return AffineDecrypt(ciphertext, c.AffineKey, c.Options...)}
+
+
+// If Symbols is nil, the first call to Encrypt generates a key (using SymbolRange) and keeps it for later calls. Encrypting again
+// with an existing key picks new random homophones from that key
+type HomophonicCipher struct {
+    Symbols map[string]rune
+    SymbolRange int
+}
+
+func (c *HomophonicCipher) Name() string {return "homophonic"}
+func (c *HomophonicCipher) Key() string {return formatSymbolMap(c.Symbols)}
+func (c *HomophonicCipher) Encrypt(plaintext string) (string, error) {
+    if c.Symbols == nil {
+        ciphertext, key, err := HomophonicEncrypt(plaintext, c.SymbolRange)
+        if err != nil {return "", err}
+        c.Symbols = key
+        return ciphertext, nil
+    }
+    if len(plaintext) <= 0 {return "", errors.New("given empty string")}
+
+    // Invert the key so that every character knows which symbols can stand in for it
+    var homophones map[rune][]string = make(map[rune][]string)
+    for symbol, char := range c.Symbols {
+        homophones[char] = append(homophones[char], symbol)
+    }
+
+    var symbols []string = make([]string, 0, len(plaintext))
+    for _, cur := range plaintext {
+        choices := homophones[cur]
+        if len(choices) <= 0 {return "", errors.New("key has no symbol for " + strconv.QuoteRune(cur))}
+        symbols = append(symbols, choices[rand.IntN(len(choices))])
+    }
+
+    return strings.Join(symbols, " "), nil
+}
+func (c *HomophonicCipher) Decrypt(ciphertext string) (string, error) {return HomophonicDecrypt(ciphertext, c.Symbols)}
+
+
+// Keytext is the whole book, not a file name; the command line tool's -keyfile is the easy way to hand one over
+type BookCipher struct {
+    Keytext string
+    Scheme BookScheme
+    Options []Option
+}
+
+func (c *BookCipher) Name() string {return "book"}
+func (c *BookCipher) Key() string {return c.Keytext}
+func (c *BookCipher) Encrypt(plaintext string) (string, error) {return BookEncrypt(plaintext, c.Keytext, c.Scheme, c.Options...)}
+func (c *BookCipher) Decrypt(ciphertext string) (string, error) {return BookDecrypt(ciphertext, c.Keytext, c.Scheme, c.Options...)}
+
+
+// Matrix is the n×n key matrix, which HillMatrix makes out of a keyword. Key() spells it back out as that keyword
+type HillCipher struct {
+    Matrix [][]int
+    Options []Option
+}
+
+func (c *HillCipher) Name() string {return "hill"}
+func (c *HillCipher) Key() string {return hillKeyword(c.Matrix)}
+func (c *HillCipher) Encrypt(plaintext string) (string, error) {return HillEncrypt(plaintext, c.Matrix, c.Options...)}
+func (c *HillCipher) Decrypt(ciphertext string) (string, error) {return HillDecrypt(ciphertext, c.Matrix, c.Options...)}
+
+
+type PlayfairCipher struct {
+    Keyphrase string
+    Options []Option
+}
+
+func (c *PlayfairCipher) Name() string {return "playfair"}
+func (c *PlayfairCipher) Key() string {return c.Keyphrase}
+func (c *PlayfairCipher) Encrypt(plaintext string) (string, error) {return PlayfairEncrypt(plaintext, c.Keyphrase, c.Options...)}
+func (c *PlayfairCipher) Decrypt(ciphertext string) (string, error) {return PlayfairDecrypt(ciphertext, c.Keyphrase, c.Options...)}
+
+
+type VigenereCipher struct {
+    Keytext string
+    Options []Option
+}
+
+func (c *VigenereCipher) Name() string {return "vigenere"}
+func (c *VigenereCipher) Key() string {return c.Keytext}
+func (c *VigenereCipher) Encrypt(plaintext string) (string, error) {return VigenereEncrypt(plaintext, c.Keytext, c.Options...)}
+func (c *VigenereCipher) Decrypt(ciphertext string) (string, error) {return VigenereDecrypt(ciphertext, c.Keytext, c.Options...)}
+
+
+type BeaufortCipher struct {
+    Keytext string
+    Options []Option
+}
+
+func (c *BeaufortCipher) Name() string {return "beaufort"}
+func (c *BeaufortCipher) Key() string {return c.Keytext}
+func (c *BeaufortCipher) Encrypt(plaintext string) (string, error) {return BeaufortEncrypt(plaintext, c.Keytext, c.Options...)}
+func (c *BeaufortCipher) Decrypt(ciphertext string) (string, error) {return BeaufortDecrypt(ciphertext, c.Keytext, c.Options...)}
+
+
+type VariantBeaufortCipher struct {
+    Keytext string
+    Options []Option
+}
+
+func (c *VariantBeaufortCipher) Name() string {return "variantbeaufort"}
+func (c *VariantBeaufortCipher) Key() string {return c.Keytext}
+func (c *VariantBeaufortCipher) Encrypt(plaintext string) (string, error) {return VariantBeaufortEncrypt(plaintext, c.Keytext, c.Options...)}
+func (c *VariantBeaufortCipher) Decrypt(ciphertext string) (string, error) {return VariantBeaufortDecrypt(ciphertext, c.Keytext, c.Options...)}
+
+
+// Digits is the key as a string of digits, like "31415"
+type GronsfeldCipher struct {
+    Digits string
+    Options []Option
+}
+
+func (c *GronsfeldCipher) Name() string {return "gronsfeld"}
+func (c *GronsfeldCipher) Key() string {return c.Digits}
+func (c *GronsfeldCipher) Encrypt(plaintext string) (string, error) {return GronsfeldEncrypt(plaintext, c.Digits, c.Options...)}
+func (c *GronsfeldCipher) Decrypt(ciphertext string) (string, error) {return GronsfeldDecrypt(ciphertext, c.Digits, c.Options...)}
+
+
+type AutokeyCipher struct {
+    Primer string
+    Options []Option
+}
+
+func (c *AutokeyCipher) Name() string {return "autokey"}
+func (c *AutokeyCipher) Key() string {return c.Primer}
+func (c *AutokeyCipher) Encrypt(plaintext string) (string, error) {return AutokeyEncrypt(plaintext, c.Primer, c.Options...)}
+func (c *AutokeyCipher) Decrypt(ciphertext string) (string, error) {return AutokeyDecrypt(ciphertext, c.Primer, c.Options...)}
+
+
+// Keytext is the whole book, the same as for BookCipher
+type RunningKeyCipher struct {
+    Keytext string
+    Options []Option
+}
+
+func (c *RunningKeyCipher) Name() string {return "runningkey"}
+func (c *RunningKeyCipher) Key() string {return c.Keytext}
+func (c *RunningKeyCipher) Encrypt(plaintext string) (string, error) {return RunningKeyEncrypt(plaintext, c.Keytext, c.Options...)}
+func (c *RunningKeyCipher) Decrypt(ciphertext string) (string, error) {return RunningKeyDecrypt(ciphertext, c.Keytext, c.Options...)}
+
+
+// If Pad is nil, the first call to Encrypt generates one as long as the plaintext. A pad can only encrypt one message, since
+// reusing it is the one thing you're never supposed to do with a one time pad, so every call to Encrypt after the first is an
+// error. So is a pad with fewer letters than the plaintext. Decrypt can be called as often as you like
+type OTPCipher struct {
+    Pad []rune
+    Options []Option
+
+    used bool
+}
+
+func (c *OTPCipher) Name() string {return "otp"}
+func (c *OTPCipher) Key() string {return string(c.Pad)}
+func (c *OTPCipher) Encrypt(plaintext string) (string, error) {
+    if c.used {return "", errors.New("pad has already encrypted a message")}
+
+    if c.Pad == nil {
+        ciphertext, pad, err := OTPEncrypt(plaintext, c.Options...)
+        if err != nil {return "", err}
+        c.Pad, c.used = pad, true
+        return ciphertext, nil
+    }
+
+    if err := checkKeyLength(plaintext, string(c.Pad), "pad", getOptions(c.Options)); err != nil {return "", err}
+    ciphertext, err := VigenereEncrypt(plaintext, string(c.Pad), c.Options...)
+    if err != nil {return "", err}
+    c.used = true
+    return ciphertext, nil
+}
+func (c *OTPCipher) Decrypt(ciphertext string) (string, error) {return OTPDecrypt(ciphertext, c.Pad, c.Options...)}
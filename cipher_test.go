@@ -0,0 +1,100 @@
+package ciphers
+
+import (
+	"testing"
+)
+
+func TestCipherInterface(t *testing.T) {
+	const PLAINTEXT string = "THYSECRETISTHYPRISONERIFTHOULETITGOTHOUARTAPRISONERTOIT"
+
+	var all []Cipher = []Cipher{
+		&RailfenceCipher{},
+		&RailfenceCipher{Rails: 5, Offset: 3},
+		&ColumnarCipher{Keyword: "ZEBRAS"},
+		&DoubleColumnarCipher{First: "ZEBRAS", Second: "STRIPE"},
+		&MVPCCipher{},
+		&ROTXCipher{Offset: 14},
+		&CaesarCipher{},
+		&KeyphraseCipher{Keyphrase: "JULIUS CAESAR"},
+		&AtbashCipher{},
+		&AffineCipher{AffineKey: AffineKey{Multiplier: 5, Offset: 8}},
+		&AffineCipher{AffineKey: AtbashAffineKey},
+		&HomophonicCipher{SymbolRange: 1000},
+		// 5 letter blocks, since the plaintext is 55 letters long and any nulls would still be there after decrypting
+		&HillCipher{Matrix: [][]int{{12, 0, 19, 17, 8}, {23, 12, 20, 11, 19}, {8, 15, 11, 8, 2}, {0, 19, 8, 14, 13}, {22, 14, 17, 10, 18}}},
+		&VigenereCipher{Keytext: "ANDYETEMANCIPATEDITMUSTBE"},
+		&BeaufortCipher{Keytext: "FORTIFICATION"},
+		&VariantBeaufortCipher{Keytext: "LEMON"},
+		&GronsfeldCipher{Digits: "31415"},
+		&AutokeyCipher{Primer: "QUEENLY"},
+		&RunningKeyCipher{Keytext: "When in the Course of human events, it becomes necessary for one people to dissolve the political bands"},
+		&OTPCipher{},
+	}
+
+	for _, c := range all {
+		res1, err := c.Encrypt(PLAINTEXT)
+		if len(res1) <= 0 || err != nil {
+			t.Errorf("Got incorrect string from %v encryption: %v (%v)", c.Name(), res1, err)
+		}
+
+		res2, err := c.Decrypt(res1)
+		if res2 != PLAINTEXT || err != nil {
+			t.Errorf("Got incorrect string from %v decryption: %v %v (%v)", c.Name(), res2, c.Key(), err)
+		}
+
+		// Generated keys have to stick around, so encrypting a second time must still decrypt. The exception is the one time pad,
+		// which won't encrypt a second message at all
+		res3, err := c.Encrypt(PLAINTEXT)
+		if c.Name() == "otp" {
+			if err == nil {
+				t.Errorf("Encrypted a second message with the same one time pad: %v", res3)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Got error from second %v encryption: %v", c.Name(), err)
+		}
+		res4, err := c.Decrypt(res3)
+		if res4 != PLAINTEXT || err != nil {
+			t.Errorf("Got incorrect string from second %v decryption: %v (%v)", c.Name(), res4, err)
+		}
+	}
+}
+
+func TestCipherKeys(t *testing.T) {
+	mvpc := &MVPCCipher{Pairs: map[rune]rune{'A': 'V', 'V': 'A', 'B': 'H', 'H': 'B'}}
+	if res := mvpc.Key(); res != "AV BH HB VA" {
+		t.Errorf("Got incorrect MVPC key: %v", res)
+	}
+
+	homophonic := &HomophonicCipher{Symbols: map[string]rune{"12": 'T', "7": ' ', "103": ','}}
+	if res := homophonic.Key(); res != `7:' ' 12:'T' 103:','` {
+		t.Errorf("Got incorrect homophonic key: %v", res)
+	}
+
+	rotx := &ROTXCipher{Offset: 14}
+	if res := rotx.Key(); res != "14" {
+		t.Errorf("Got incorrect ROTX key: %v", res)
+	}
+
+	affine := &AffineCipher{AffineKey: AtbashAffineKey}
+	if res := affine.Key(); res != "-1,-1" {
+		t.Errorf("Got incorrect affine key: %v", res)
+	}
+
+	hill := &HillCipher{Matrix: [][]int{{3, 3}, {-24, 31}}}
+	if res := hill.Key(); res != "DDCF" {
+		t.Errorf("Got incorrect Hill key: %v", res)
+	}
+
+	// Playfair leaves its fillers in, so it can't go in the list above
+	playfair := &PlayfairCipher{Keyphrase: "Playfair example"}
+	if res, err := playfair.Encrypt("Hide the gold in the tree stump"); res != "BMODZBXDNABEKUDMUIXMMOUVIF" || playfair.Key() != "Playfair example" || err != nil {
+		t.Errorf("Got incorrect string from Playfair encryption: %v (%v)", res, err)
+	}
+
+	railfence := &RailfenceCipher{Rails: 3, Offset: 1}
+	if res := railfence.Key(); res != "3,1" {
+		t.Errorf("Got incorrect rail fence key: %v", res)
+	}
+}
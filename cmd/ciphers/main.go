@@ -0,0 +1,458 @@
+/** ciphers - a small command line front end for the ciphers package
+
+Every experiment with this package used to mean writing a throwaway main function, so this is that main function done once. Text is
+read from stdin (or -in) and written to stdout (or -out), so it can be chained together in a shell like any other filter:
+
+    echo "VENI, VIDI, VICI" | ciphers encrypt -cipher caesar
+    ciphers encrypt -cipher vigenere -key ANDYETEMANCIPATEDITMUSTBE -in message.txt
+    ciphers encrypt -cipher caesar -param alphabet=latin -in gallia.txt
+    ciphers encrypt -cipher affine -key 5,8 -in message.txt
+    ciphers encrypt -cipher hill -key GYBNQKURP -in message.txt
+    ciphers encrypt -cipher playfair -key "PLAYFAIR EXAMPLE" -param padding=Q -in message.txt
+    ciphers encrypt -cipher railfence -key 3,1 -in message.txt
+    ciphers encrypt -cipher doublecolumnar -key ZEBRAS,STRIPE -param padding=X -in message.txt
+    ciphers encrypt -cipher vigenere -key LEMON -param preserve=true -in letter.txt
+    ciphers encrypt -cipher gronsfeld -key 31415 -in message.txt
+    ciphers encrypt -cipher runningkey -keyfile book.txt -in message.txt
+    ciphers encrypt -cipher mvpc -keyout mvpc.key < message.txt > message.enc
+    ciphers decrypt -cipher mvpc -keyfile mvpc.key < message.enc
+    ciphers keygen -cipher otp -length 500 -out pad.key
+    ciphers keygen -cipher mvpc -length 1 -out mvpc.key
+    ciphers freq -in message.txt
+    ciphers freq -n 2 -strip -in message.txt
+    ciphers identify -in intercepted.txt
+    ciphers crack -cipher rotx -in intercepted.txt
+    ciphers crack -cipher affine -top 3 -in intercepted.txt
+    ciphers crack -cipher vigenere -top 3 -in intercepted.txt
+    ciphers crack -cipher beaufort -top 3 -in intercepted.txt
+    ciphers crack -cipher railfence -top 1 -in intercepted.txt
+    ciphers crack -cipher substitution -in intercepted.txt
+    ciphers crack -cipher playfair -in intercepted.txt
+    ciphers crack -cipher hill -size 3 -known cribs.txt -in intercepted.txt
+    ciphers crack -cipher caesar -lang latin -in commentarii.txt
+    ciphers train -name italian -min 2 -in divina-commedia.txt -out italian.model
+
+Ciphers are picked by their registry name (see `ciphers list`). Generated keys (MVPC, homophonic, one time pad) are written in the
+same form the registry reads them back in, so a key written with -keyout can be given straight back with -keyfile. A one time pad
+made with keygen has to have at least as many letters as the message it encrypts, and encrypt refuses a message that's too long
+*/
+
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/realconebob/ciphers"
+	"github.com/realconebob/ciphers/language"
+)
+
+const USAGE string = `usage: ciphers <command> [flags]
+
+commands:
+    encrypt     encrypt text with a cipher
+    decrypt     decrypt text with a cipher
+    keygen      generate a key for a cipher that makes its own keys
+    freq        print the character, n-gram or word frequencies of a text
+    identify    guess which kind of cipher made a ciphertext
+    crack       try to break a ciphertext without the key
+    list        list the available ciphers
+    train       count the n-grams of a text into a language model for -lang
+
+run "ciphers <command> -h" to see the flags for a command
+`
+
+func main() {
+    os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+    if len(args) <= 0 {
+        fmt.Fprint(stderr, USAGE)
+        return 2
+    }
+
+    var commands map[string]func([]string, io.Reader, io.Writer, io.Writer) error = map[string]func([]string, io.Reader, io.Writer, io.Writer) error{
+        "encrypt":  func(args []string, stdin io.Reader, stdout, stderr io.Writer) error {return process(args, stdin, stdout, stderr, false)},
+        "decrypt":  func(args []string, stdin io.Reader, stdout, stderr io.Writer) error {return process(args, stdin, stdout, stderr, true)},
+        "keygen":   keygen,
+        "freq":     freq,
+        "identify": identify,
+        "crack":    crack,
+        "list":     list,
+        "train":    train,
+    }
+
+    command, exists := commands[args[0]]
+    if !exists {
+        fmt.Fprintf(stderr, "unknown command %q\n\n%v", args[0], USAGE)
+        return 2
+    }
+
+    if err := command(args[1:], stdin, stdout, stderr); err != nil {
+        if errors.Is(err, flag.ErrHelp) {return 0}
+        fmt.Fprintln(stderr, "ciphers " + args[0] + ": " + err.Error())
+        return 1
+    }
+
+    return 0
+}
+
+
+// Repeatable -param name=value flag
+type paramFlag map[string]string
+
+func (p paramFlag) String() string {
+    var res []string
+    for name, value := range p {
+        res = append(res, name + "=" + value)
+    }
+    slices.Sort(res)
+    return strings.Join(res, ",")
+}
+
+func (p paramFlag) Set(value string) error {
+    name, val, found := strings.Cut(value, "=")
+    if !found || len(name) <= 0 {return errors.New("parameter should look like name=value")}
+    p[name] = val
+    return nil
+}
+
+// Flags every command that reads and writes text has
+type ioflags struct {
+    in, out string
+}
+
+func (f *ioflags) register(set *flag.FlagSet) {
+    set.StringVar(&f.in, "in", "", "read input from `file` instead of stdin")
+    set.StringVar(&f.out, "out", "", "write output to `file` instead of stdout")
+}
+
+func (f *ioflags) read(stdin io.Reader) (string, error) {
+    var input io.Reader = stdin
+    if len(f.in) > 0 {
+        file, err := os.Open(f.in)
+        if err != nil {return "", err}
+        defer file.Close()
+        input = file
+    }
+
+    text, err := io.ReadAll(input)
+    if err != nil {return "", err}
+    if len(text) <= 0 {return "", errors.New("no input")}
+
+    // Don't let the newline at the end of a file or an echo become part of the message
+    return strings.TrimRight(string(text), "\r\n"), nil
+}
+
+func (f *ioflags) write(stdout io.Writer, text string) error {
+    if len(f.out) > 0 {return os.WriteFile(f.out, []byte(text), 0644)}
+    _, err := io.WriteString(stdout, text)
+    return err
+}
+
+// Read a key file, without the trailing newline an editor may have added
+func readKey(path string) (string, error) {
+    key, err := os.ReadFile(path)
+    if err != nil {return "", err}
+    return strings.TrimRight(string(key), "\r\n"), nil
+}
+
+func newFlagSet(name string, stderr io.Writer) *flag.FlagSet {
+    set := flag.NewFlagSet(name, flag.ContinueOnError)
+    set.SetOutput(stderr)
+    return set
+}
+
+
+// encrypt and decrypt
+func process(args []string, stdin io.Reader, stdout, stderr io.Writer, decrypt bool) error {
+    var name string = "encrypt"
+    if decrypt {name = "decrypt"}
+
+    var files ioflags
+    var cipher, key, keyfile, keyout string
+    var params paramFlag = paramFlag{}
+
+    set := newFlagSet(name, stderr)
+    files.register(set)
+    set.StringVar(&cipher, "cipher", "", "`name` of the cipher to use")
+    set.StringVar(&key, "key", "", "the `key` to use")
+    set.StringVar(&keyfile, "keyfile", "", "read the key from `file`")
+    set.Var(params, "param", "extra cipher parameter as `name=value` (can be repeated)")
+    if !decrypt {
+        set.StringVar(&keyout, "keyout", "", "write a generated key to `file`")
+    }
+    if err := set.Parse(args); err != nil {return err}
+
+    if len(cipher) <= 0 {return errors.New("missing -cipher")}
+    if len(key) > 0 && len(keyfile) > 0 {return errors.New("only one of -key and -keyfile can be given")}
+    if len(keyfile) > 0 {
+        var err error
+        key, err = readKey(keyfile)
+        if err != nil {return err}
+    }
+    if len(key) > 0 {params["key"] = key}
+
+    c, err := ciphers.NewCipher(cipher, params)
+    if err != nil {return err}
+
+    text, err := files.read(stdin)
+    if err != nil {return err}
+
+    var res string
+    if decrypt {
+        res, err = c.Decrypt(text)
+    } else {
+        res, err = c.Encrypt(text)
+    }
+    if err != nil {return err}
+
+    // A cipher that wasn't given a key just made one up, and the ciphertext is useless without it
+    if !decrypt && len(key) <= 0 && len(c.Key()) > 0 {
+        if len(keyout) > 0 {
+            if err := os.WriteFile(keyout, []byte(c.Key() + "\n"), 0600); err != nil {return err}
+        } else {
+            fmt.Fprintln(stderr, "key: " + c.Key())
+        }
+    }
+
+    return files.write(stdout, res + "\n")
+}
+
+func keygen(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+    var files ioflags
+    var cipher string
+    var length int
+    var params paramFlag = paramFlag{}
+
+    set := newFlagSet("keygen", stderr)
+    files.register(set)
+    set.StringVar(&cipher, "cipher", "", "`name` of the cipher to make a key for")
+    set.IntVar(&length, "length", 0, "make a key for a message `n` letters long, instead of for the input")
+    set.Var(params, "param", "extra cipher parameter as `name=value` (can be repeated)")
+    if err := set.Parse(args); err != nil {return err}
+
+    if len(cipher) <= 0 {return errors.New("missing -cipher")}
+    if _, exists := params["key"]; exists {return errors.New("can't generate a key when given one")}
+
+    c, err := ciphers.NewCipher(cipher, params)
+    if err != nil {return err}
+
+    // Keys are generated by encrypting something with a fresh cipher. Some keys depend on the message (the length of a one time
+    // pad, the letter frequencies of a homophonic key), so that something is the input unless a length was asked for
+    var sample string
+    if length > 0 {
+        sample = strings.Repeat(ciphers.ROMANALPHA, length / len(ciphers.ROMANALPHA) + 1)[:length]
+    } else {
+        sample, err = files.read(stdin)
+        if err != nil {return err}
+    }
+
+    if _, err := c.Encrypt(sample); err != nil {return err}
+    if len(c.Key()) <= 0 {return errors.New("cipher \"" + cipher + "\" does not generate keys")}
+
+    return files.write(stdout, c.Key() + "\n")
+}
+
+func freq(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+    var files ioflags
+    var n int
+    var words, strip bool
+
+    set := newFlagSet("freq", stderr)
+    files.register(set)
+    set.IntVar(&n, "n", 1, "count runs of `n` characters instead of single ones")
+    set.BoolVar(&words, "words", false, "count words instead of characters")
+    set.BoolVar(&strip, "strip", false, "only count letters, ignoring case")
+    if err := set.Parse(args); err != nil {return err}
+
+    text, err := files.read(stdin)
+    if err != nil {return err}
+
+    var opts []ciphers.Option
+    if strip {opts = append(opts, ciphers.StripNonAlpha())}
+    var table ciphers.FrequencyTable
+    if words {
+        table, err = ciphers.WordFrequency(text, opts...)
+    } else {
+        table, err = ciphers.NGramFrequency(text, n, opts...)
+    }
+    if err != nil {return err}
+
+    // Single characters are quoted as characters, so a space or a newline still shows up as something
+    var res strings.Builder
+    for _, gram := range table.Grams {
+        if table.N == 1 {
+            fmt.Fprintf(&res, "%q\t%.2f\n", []rune(gram.Text)[0], gram.Frequency * 100)
+        } else {
+            fmt.Fprintf(&res, "%q\t%.2f\n", gram.Text, gram.Frequency * 100)
+        }
+    }
+
+    return files.write(stdout, res.String())
+}
+
+func identify(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+    var files ioflags
+    var lang string
+
+    set := newFlagSet("identify", stderr)
+    files.register(set)
+    set.StringVar(&lang, "lang", "english", "`language` of the plaintext: a built in one, or a model file written by train")
+    if err := set.Parse(args); err != nil {return err}
+
+    text, err := files.read(stdin)
+    if err != nil {return err}
+    model, err := loadLanguage(lang)
+    if err != nil {return err}
+    profile, err := ciphers.IdentifyCipher(text, ciphers.WithLanguage(model))
+    if err != nil {return err}
+
+    // The measurements first, then one guess per line: family, confidence, and the ciphers to try crack with
+    var res strings.Builder
+    fmt.Fprintf(&res, "format\t%v\nlength\t%v\nsymbols\t%v\nioc\t%.4f\nentropy\t%.2f\nperiod\t%v\n\n", profile.Format, profile.Length,
+        profile.Symbols, profile.IoC, profile.Entropy, profile.Period)
+    for _, guess := range profile.Guesses {
+        fmt.Fprintf(&res, "%v\t%.1f%%\t%v\n", guess.Family, guess.Confidence * 100, strings.Join(guess.Ciphers, ","))
+    }
+
+    return files.write(stdout, res.String())
+}
+
+func crack(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+    var files ioflags
+    var cipher, lang, known string
+    var top, size int
+
+    set := newFlagSet("crack", stderr)
+    files.register(set)
+    set.StringVar(&cipher, "cipher", "rotx", "`name` of the cipher the text was encrypted with")
+    set.IntVar(&top, "top", 5, "print at most `n` candidates, best first")
+    set.StringVar(&lang, "lang", "english", "`language` of the plaintext: a built in one, or a model file written by train")
+    set.StringVar(&known, "known", "", "read known plaintext from `file`, for ciphers that need it (hill)")
+    set.IntVar(&size, "size", 2, "block size of the key matrix (hill)")
+    if err := set.Parse(args); err != nil {return err}
+
+    text, err := files.read(stdin)
+    if err != nil {return err}
+    model, err := loadLanguage(lang)
+    if err != nil {return err}
+    opts := []ciphers.Option{ciphers.WithLanguage(model)}
+
+    var res strings.Builder
+    switch cipher {
+    case "rotx", "caesar":
+        candidates, err := ciphers.CrackROTX(text, opts...)
+        if err != nil {return err}
+        writeCandidates(&res, candidates, top)
+    case "affine":
+        candidates, err := ciphers.CrackAffine(text, opts...)
+        if err != nil {return err}
+        writeCandidates(&res, candidates, top)
+    case "railfence":
+        candidates, err := ciphers.CrackRailfence(text, opts...)
+        if err != nil {return err}
+        writeCandidates(&res, candidates, top)
+    case "vigenere", "beaufort", "variantbeaufort":
+        // Same attack on all three, just with a different tableau
+        var tableaus map[string]ciphers.Tableau = map[string]ciphers.Tableau{
+            "vigenere":         ciphers.VigenereTableau,
+            "beaufort":         ciphers.BeaufortTableau,
+            "variantbeaufort":  ciphers.VariantBeaufortTableau,
+        }
+        candidates, err := ciphers.CrackVigenere(text, append(opts, ciphers.WithTableau(tableaus[cipher]))...)
+        if err != nil {return err}
+        writeCandidates(&res, candidates, top)
+    case "gronsfeld":
+        candidates, err := ciphers.CrackGronsfeld(text, opts...)
+        if err != nil {return err}
+        writeCandidates(&res, candidates, top)
+    case "hill":
+        // Known plaintext gives up the key, which then decrypts the rest of the ciphertext
+        if len(known) <= 0 {return errors.New("cracking hill needs known plaintext (-known)")}
+        plaintext, err := os.ReadFile(known)
+        if err != nil {return err}
+        matrix, err := ciphers.CrackHill(string(plaintext), text, size)
+        if err != nil {return err}
+        c := &ciphers.HillCipher{Matrix: matrix}
+        decrypted, err := c.Decrypt(text)
+        if err != nil {return err}
+        fmt.Fprintf(&res, "%v\t%v\n", c.Key(), decrypted)
+    case "keyphrase", "mvpc", "substitution":
+        // Only one answer comes out of the hill climber, and its key is written the same way the mvpc cipher takes it
+        plaintext, key, err := ciphers.CrackSubstitution(text, opts...)
+        if err != nil {return err}
+        fmt.Fprintf(&res, "%v\t%v\n", (&ciphers.MVPCCipher{Pairs: key}).Key(), plaintext)
+    case "playfair":
+        // The square comes out row by row, which the playfair cipher takes as its keyphrase
+        plaintext, square, err := ciphers.CrackPlayfair(text, opts...)
+        if err != nil {return err}
+        fmt.Fprintf(&res, "%v\t%v\n", square, plaintext)
+    case "homophonic":
+        plaintext, key, err := ciphers.CrackHomophonic(text, opts...)
+        if err != nil {return err}
+        fmt.Fprintf(&res, "%v\t%v\n", (&ciphers.HomophonicCipher{Symbols: key}).Key(), plaintext)
+    default:
+        return errors.New("don't know how to crack \"" + cipher + "\"")
+    }
+
+    return files.write(stdout, res.String())
+}
+
+// A built in language by name, or else a model file
+func loadLanguage(name string) (*language.Model, error) {
+    if model, exists := language.Languages[name]; exists {return model, nil}
+
+    file, err := os.Open(name)
+    if err != nil {return nil, errors.New("language \"" + name + "\" is not a built in language or a model file")}
+    defer file.Close()
+    return language.Load(name, file)
+}
+
+// One candidate per line: key, score, plaintext
+func writeCandidates(w io.Writer, candidates []ciphers.Candidate, top int) {
+    for i, cur := range candidates {
+        if top > 0 && i >= top {break}
+        fmt.Fprintf(w, "%v\t%.2f\t%v\n", cur.Key, cur.Score, cur.Plaintext)
+    }
+}
+
+func train(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+    var files ioflags
+    var name, comment string
+    var prune float64
+
+    set := newFlagSet("train", stderr)
+    files.register(set)
+    set.StringVar(&name, "name", "custom", "`name` of the language")
+    set.StringVar(&comment, "comment", "", "write `text` at the top of the model, to say where it came from")
+    set.Float64Var(&prune, "min", 0, "leave out 3 and 4 letter n-grams seen fewer than `n` times")
+    if err := set.Parse(args); err != nil {return err}
+
+    text, err := files.read(stdin)
+    if err != nil {return err}
+    model, err := language.Train(name, strings.NewReader(text))
+    if err != nil {return err}
+    if prune > 0 {model.Prune(prune)}
+
+    var comments []string
+    if len(comment) > 0 {comments = strings.Split(comment, "\n")}
+    var res strings.Builder
+    if err := model.Save(&res, comments...); err != nil {return err}
+
+    return files.write(stdout, res.String())
+}
+
+func list(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+    set := newFlagSet("list", stderr)
+    if err := set.Parse(args); err != nil {return err}
+
+    _, err := io.WriteString(stdout, strings.Join(ciphers.RegisteredCiphers(), "\n") + "\n")
+    return err
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Run the tool like it would be run from a shell, returning what it wrote to stdout
+func runTool(t *testing.T, input string, args ...string) string {
+	var stdout, stderr bytes.Buffer
+
+	if code := run(args, strings.NewReader(input), &stdout, &stderr); code != 0 {
+		t.Fatalf("ciphers %v exited with %v: %v", strings.Join(args, " "), code, stderr.String())
+	}
+
+	return stdout.String()
+}
+
+func TestEncryptDecrypt(t *testing.T) {
+	const PLAINTEXT string	= "VENI, VIDI, VICI\n"
+	const CIPHERTEXT string	= "YHQLYLGLYLFL\n"
+
+	res1 := runTool(t, PLAINTEXT, "encrypt", "-cipher", "caesar")
+	if res1 != CIPHERTEXT {
+		t.Errorf("Got incorrect string from caesar encryption: %v", res1)
+	}
+
+	res2 := runTool(t, res1, "decrypt", "-cipher", "rotx", "-key", "3")
+	if res2 != "VENIVIDIVICI\n" {
+		t.Errorf("Got incorrect string from rotx decryption: %v", res2)
+	}
+}
+
+func TestGeneratedKeys(t *testing.T) {
+	const PLAINTEXT string = "THYSECRETISTHYPRISONERIFTHOULETITGOTHOUARTAPRISONERTOIT"
+	dir := t.TempDir()
+
+	for _, cipher := range []string{"mvpc", "otp", "homophonic"} {
+		keyfile := filepath.Join(dir, cipher + ".key")
+
+		ciphertext := runTool(t, PLAINTEXT, "encrypt", "-cipher", cipher, "-keyout", keyfile)
+		if _, err := os.Stat(keyfile); err != nil {
+			t.Fatalf("%v key was not written: %v", cipher, err)
+		}
+
+		res := runTool(t, ciphertext, "decrypt", "-cipher", cipher, "-keyfile", keyfile)
+		if res != PLAINTEXT + "\n" {
+			t.Errorf("Got incorrect string from %v decryption with saved key: %v", cipher, res)
+		}
+	}
+
+	// A pad made ahead of time has to be usable for encryption and decryption later on
+	padfile := filepath.Join(dir, "pad.key")
+	runTool(t, "", "keygen", "-cipher", "otp", "-length", "100", "-out", padfile)
+
+	ciphertext := runTool(t, PLAINTEXT, "encrypt", "-cipher", "otp", "-keyfile", padfile)
+	res := runTool(t, ciphertext, "decrypt", "-cipher", "otp", "-keyfile", padfile)
+	if res != PLAINTEXT + "\n" {
+		t.Errorf("Got incorrect string from otp decryption with generated pad: %v", res)
+	}
+
+	// A message longer than the pad can't be encrypted with it, since the pad would have to wrap around
+	var stdout, stderr bytes.Buffer
+	args := []string{"encrypt", "-cipher", "otp", "-keyfile", padfile}
+	if code := run(args, strings.NewReader(strings.Repeat(PLAINTEXT, 2)), &stdout, &stderr); code == 0 || stdout.Len() > 0 {
+		t.Errorf("Encrypted a message longer than the pad: %v", stdout.String())
+	}
+	if !strings.Contains(stderr.String(), "pad has 100 letters, but the text needs 110") {
+		t.Errorf("Got incorrect error for a message longer than the pad: %v", stderr.String())
+	}
+}
+
+func TestFreq(t *testing.T) {
+	res := runTool(t, "AAB", "freq")
+	if res != "'A'\t66.67\n'B'\t33.33\n" {
+		t.Errorf("Got incorrect frequency table: %v", res)
+	}
+
+	res = runTool(t, "The cat, the hat.", "freq", "-n", "2", "-strip")
+	if !strings.HasPrefix(res, "\"AT\"\t18.18\n\"HE\"\t18.18\n") || strings.Count(res, "\n") != 8 {
+		t.Errorf("Got incorrect bigram table: %v", res)
+	}
+
+	res = runTool(t, "The cat, the hat.", "freq", "-words", "-strip")
+	if res != "\"THE\"\t50.00\n\"CAT\"\t25.00\n\"HAT\"\t25.00\n" {
+		t.Errorf("Got incorrect word table: %v", res)
+	}
+}
+
+func TestCrack(t *testing.T) {
+	res := runTool(t, "DWWDFN DW GDZQ IURP WKH QRUWK", "crack", "-cipher", "caesar")
+	if !strings.HasPrefix(res, "3\t") || !strings.Contains(res, "\tATTACKATDAWNFROMTHENORTH\n") || strings.Count(res, "\n") != 5 {
+		t.Errorf("Brute force did not put the right offset first: %v", res)
+	}
+
+	const PLAINTEXT string = "WHENINTHECOURSEOFHUMANEVENTSITBECOMESNECESSARYFORONEPEOPLETODISSOLVETHEPOLITICALBANDSWHICHHAVECONNECTED" +
+		"THEMWITHANOTHERANDTOASSUMEAMONGTHEPOWERSOFTHEEARTHTHESEPARATEANDEQUALSTATIONTOWHICHTHELAWSOFNATUREANDOFNATURESGOD"
+	ciphertext := runTool(t, PLAINTEXT, "encrypt", "-cipher", "vigenere", "-key", "LEMON")
+	res = runTool(t, ciphertext, "crack", "-cipher", "vigenere", "-top", "1")
+	if !strings.HasPrefix(res, "LEMON\t") || !strings.HasSuffix(res, "\t" + PLAINTEXT + "\n") || strings.Count(res, "\n") != 1 {
+		t.Errorf("Got incorrect best candidate from cracking Vigenere: %v", res)
+	}
+
+	ciphertext = runTool(t, PLAINTEXT, "encrypt", "-cipher", "beaufort", "-key", "FORTIFICATION")
+	res = runTool(t, ciphertext, "crack", "-cipher", "beaufort", "-top", "1")
+	if !strings.HasPrefix(res, "FORTIFICATION\t") || !strings.HasSuffix(res, "\t" + PLAINTEXT + "\n") {
+		t.Errorf("Got incorrect best candidate from cracking Beaufort: %v", res)
+	}
+
+	ciphertext = runTool(t, PLAINTEXT, "encrypt", "-cipher", "gronsfeld", "-key", "31415")
+	res = runTool(t, ciphertext, "crack", "-cipher", "gronsfeld", "-top", "1")
+	if !strings.HasPrefix(res, "31415\t") || !strings.HasSuffix(res, "\t" + PLAINTEXT + "\n") {
+		t.Errorf("Got incorrect best candidate from cracking Gronsfeld: %v", res)
+	}
+
+	ciphertext = runTool(t, PLAINTEXT, "encrypt", "-cipher", "railfence", "-key", "4,2")
+	res = runTool(t, ciphertext, "crack", "-cipher", "railfence", "-top", "1")
+	if !strings.HasPrefix(res, "4,2\t") || !strings.HasSuffix(res, "\t" + PLAINTEXT + "\n") || strings.Count(res, "\n") != 1 {
+		t.Errorf("Got incorrect best candidate from cracking rail fence: %v", res)
+	}
+
+	ciphertext = runTool(t, PLAINTEXT, "encrypt", "-cipher", "affine", "-key", "5,8")
+	res = runTool(t, ciphertext, "crack", "-cipher", "affine", "-top", "1")
+	if !strings.HasPrefix(res, "5,8\t") || !strings.HasSuffix(res, "\t" + PLAINTEXT + "\n") || strings.Count(res, "\n") != 1 {
+		t.Errorf("Got incorrect best candidate from cracking affine: %v", res)
+	}
+
+	// The first sentence is enough known plaintext for a 3×3 key
+	known := filepath.Join(t.TempDir(), "known.txt")
+	if err := os.WriteFile(known, []byte(PLAINTEXT[:60]), 0644); err != nil {
+		t.Fatalf("Could not write known plaintext: %v", err)
+	}
+	ciphertext = runTool(t, PLAINTEXT, "encrypt", "-cipher", "hill", "-key", "GYBNQKURP")
+	res = runTool(t, ciphertext, "crack", "-cipher", "hill", "-size", "3", "-known", known)
+	if res != "GYBNQKURP\t" + PLAINTEXT + "\n" {
+		t.Errorf("Got incorrect key from cracking Hill: %v", res)
+	}
+
+	ciphertext = runTool(t, PLAINTEXT, "encrypt", "-cipher", "keyphrase", "-key", "JULIUS CAESAR")
+	res = runTool(t, ciphertext, "crack", "-cipher", "substitution")
+	if !strings.HasSuffix(res, "\t" + PLAINTEXT + "\n") || strings.Count(res, "\n") != 1 {
+		t.Errorf("Got incorrect plaintext from cracking substitution: %v", res)
+	}
+}
+
+func TestIdentify(t *testing.T) {
+	const PLAINTEXT string = "WHENINTHECOURSEOFHUMANEVENTSITBECOMESNECESSARYFORONEPEOPLETODISSOLVETHEPOLITICALBANDSWHICHHAVECONNECTED" +
+		"THEMWITHANOTHERANDTOASSUMEAMONGTHEPOWERSOFTHEEARTHTHESEPARATEANDEQUALSTATIONTOWHICHTHELAWSOFNATUREANDOFNATURESGOD"
+	ciphertext := runTool(t, PLAINTEXT, "encrypt", "-cipher", "vigenere", "-key", "LEMON")
+	res := runTool(t, ciphertext, "identify")
+	if !strings.HasPrefix(res, "format\tletters\nlength\t216\n") || !strings.Contains(res, "\nperiod\t5\n\npolyalphabetic\t") {
+		t.Errorf("Got incorrect identification of Vigenere: %v", res)
+	}
+}
+
+func TestTrain(t *testing.T) {
+	// Train a model on some Latin, then crack a Caesar cipher with it
+	model := filepath.Join(t.TempDir(), "latin.model")
+	runTool(t, "", "train", "-name", "latin", "-in", "../../language/testdata/latin.txt", "-out", model, "-comment", "Test model")
+	saved, err := os.ReadFile(model)
+	if err != nil || !strings.HasPrefix(string(saved), "# Test model\n# total 1 ") {
+		t.Fatalf("Got incorrect model from training: %.40q (%v)", saved, err)
+	}
+
+	const PLAINTEXT string = "ROMANIMILITESURBEMHOSTIUMOPPUGNAVERUNTETMAGNAMPRAEDAMCEPERUNT"
+	ciphertext := runTool(t, PLAINTEXT, "encrypt", "-cipher", "caesar")
+	for _, lang := range []string{model, "latin"} {
+		res := runTool(t, ciphertext, "crack", "-cipher", "caesar", "-lang", lang, "-top", "1")
+		if !strings.HasPrefix(res, "3\t") || !strings.HasSuffix(res, "\t" + PLAINTEXT + "\n") || strings.Count(res, "\n") != 1 {
+			t.Errorf("Got incorrect best candidate from cracking Latin with %v: %v", lang, res)
+		}
+	}
+}
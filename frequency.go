@@ -0,0 +1,155 @@
+/** FREQUENCY ANALYSIS
+
+Frequency analysis is where cryptanalysis starts: count how often everything in the ciphertext turns up, and line the
+counts up against what you'd expect from the language. CharacterFrequency only does the first half of that, and only for single
+characters. The functions in this file count n-grams (runs of n characters in a row: "TH" is a bigram, "THE" is a trigram) and whole
+words too, and hand them back as a table, most common first, that can be compared against a reference table for the language
+
+By default everything is counted exactly as it appears in the text, spaces and punctuation included, the same way
+CharacterFrequency does it. For anything to do with breaking ciphers that's rarely what you want, so StripNonAlpha runs the text
+through the alphabet first (see Alphabet.Strip): lowercase letters are counted as uppercase and everything else is thrown away. That
+also means n-grams run straight across the gaps between words, which is how they're normally counted for cryptanalysis
+*/
+
+package ciphers
+
+import (
+	"errors"
+	"math"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// One row of a frequency table
+type Gram struct {
+	Text string
+	Count int
+	// Count as a fraction of every gram counted
+	Frequency float64
+}
+
+type FrequencyTable struct {
+	// How many characters long each gram is, or 0 for a table of words
+	N int
+	// How many grams were counted in total
+	Total int
+	// Most common first. Ties are in alphabetical order, so the same text always gives the same table
+	Grams []Gram
+}
+
+// Turn counts into a sorted table
+func frequencyTable(n int, counts map[string]int, total int) FrequencyTable {
+    var grams []Gram = make([]Gram, 0, len(counts))
+    for text, count := range counts {
+        grams = append(grams, Gram{Text: text, Count: count, Frequency: float64(count) / float64(total)})
+    }
+    slices.SortFunc(grams, func(a, b Gram) int {
+        if a.Count != b.Count {return b.Count - a.Count}
+        return strings.Compare(a.Text, b.Text)
+    })
+
+    return FrequencyTable{N: n, Total: total, Grams: grams}
+}
+
+// Count every run of n characters in the text, overlapping ones included ("THEE" has the bigrams TH, HE and EE). Takes
+// StripNonAlpha, and WithAlphabet to pick which letters StripNonAlpha keeps
+func NGramFrequency(text string, n int, opts ...Option) (FrequencyTable, error) {
+    if n <= 0 {return FrequencyTable{}, errors.New("given n-gram length less than 1")}
+    o := getOptions(opts)
+    if o.strip {
+        var err error
+        text, err = o.alphabet.Strip(text)
+        if err != nil {return FrequencyTable{}, err}
+    }
+
+    var chars []rune = []rune(text)
+    if len(chars) < n {return FrequencyTable{}, errors.New("text is shorter than one n-gram")}
+
+    var counts map[string]int = make(map[string]int)
+    for i := 0; i + n <= len(chars); i++ {
+        counts[string(chars[i:i + n])]++
+    }
+
+    return frequencyTable(n, counts, len(chars) - n + 1), nil
+}
+
+// Count every word in the text. Words are split on whitespace and kept exactly as written, punctuation and all, unless
+// StripNonAlpha is given, in which case anything that isn't a letter splits words and the letters are normalized
+func WordFrequency(text string, opts ...Option) (FrequencyTable, error) {
+    o := getOptions(opts)
+
+    var words []string
+    if o.strip {
+        words = strings.FieldsFunc(text, func(cur rune) bool {
+            _, ok := o.alphabet.Normalize(cur)
+            return !ok
+        })
+        for i, word := range words {
+            words[i], _ = o.alphabet.Strip(word)
+        }
+    } else {
+        words = strings.FieldsFunc(text, unicode.IsSpace)
+    }
+    if len(words) <= 0 {return FrequencyTable{}, errors.New("given text with no words")}
+
+    var counts map[string]int = make(map[string]int)
+    for _, word := range words {
+        counts[word]++
+    }
+
+    return frequencyTable(0, counts, len(words)), nil
+}
+
+// The table as a map from each gram to its frequency, the same shape as a reference table
+func (t FrequencyTable) Frequencies() map[string]float64 {
+    var res map[string]float64 = make(map[string]float64, len(t.Grams))
+    for _, gram := range t.Grams {
+        res[gram.Text] = gram.Frequency
+    }
+    return res
+}
+
+// How far one gram is from the reference table. Both are fractions of the total, like Gram.Frequency
+type Deviation struct {
+	Text string
+	Observed float64
+	Expected float64
+}
+
+// Compare the table against a reference table (gram -> frequency, such as a language model's Frequencies). Returns the chi-squared
+// statistic, which is 0 for a perfect match and grows the less the counts look like the reference, and every gram in either table,
+// furthest from the reference first
+//
+// The chi-squared statistic only makes sense for grams the reference expects to see at all, so anything the reference doesn't have
+// is left out of it. It still shows up in the deviations
+func (t FrequencyTable) Compare(reference map[string]float64) (float64, []Deviation) {
+    var observed map[string]float64 = t.Frequencies()
+    var deviations []Deviation = make([]Deviation, 0, len(observed))
+    var chisquared float64
+
+    for text, freq := range observed {
+        deviations = append(deviations, Deviation{Text: text, Observed: freq, Expected: reference[text]})
+    }
+    for text, freq := range reference {
+        if _, exists := observed[text]; !exists {deviations = append(deviations, Deviation{Text: text, Expected: freq})}
+    }
+
+    for _, cur := range deviations {
+        if cur.Expected <= 0 {continue}
+        expected := cur.Expected * float64(t.Total)
+        diff := cur.Observed * float64(t.Total) - expected
+        chisquared += diff * diff / expected
+    }
+
+    slices.SortFunc(deviations, func(a, b Deviation) int {
+        da, db := math.Abs(a.Observed - a.Expected), math.Abs(b.Observed - b.Expected)
+        if da != db {
+            if da > db {return -1}
+            return 1
+        }
+        return strings.Compare(a.Text, b.Text)
+    })
+
+    return chisquared, deviations
+}
@@ -0,0 +1,87 @@
+package ciphers
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/realconebob/ciphers/language"
+)
+
+func TestNGramFrequency(t *testing.T) {
+	res1, err := NGramFrequency("THEE", 2)
+	want1 := []Gram{{"EE", 1, 1.0 / 3}, {"HE", 1, 1.0 / 3}, {"TH", 1, 1.0 / 3}}
+	if !slices.Equal(res1.Grams, want1) || res1.N != 2 || res1.Total != 3 || err != nil {
+		t.Errorf("Got incorrect bigram table: %+v (%v)", res1, err)
+	}
+
+	// Without StripNonAlpha the spaces and lowercase letters count the same as anything else
+	res2, err := NGramFrequency("the THE", 3)
+	if res2.Total != 5 || len(res2.Grams) != 5 || err != nil {
+		t.Errorf("Got incorrect raw trigram table: %+v (%v)", res2, err)
+	}
+	res3, err := NGramFrequency("the THE", 3, StripNonAlpha())
+	want3 := []Gram{{"THE", 2, 0.5}, {"ETH", 1, 0.25}, {"HET", 1, 0.25}}
+	if !slices.Equal(res3.Grams, want3) || err != nil {
+		t.Errorf("Got incorrect stripped trigram table: %+v (%v)", res3, err)
+	}
+
+	if _, err := NGramFrequency("AB", 3); err == nil {
+		t.Errorf("Counted trigrams in a text that's too short for one")
+	}
+	if _, err := NGramFrequency("ABC", 0); err == nil {
+		t.Errorf("Counted n-grams with no length")
+	}
+}
+
+func TestWordFrequency(t *testing.T) {
+	const PLAINTEXT string = "The cat, the hat. THE END"
+
+	res1, err := WordFrequency(PLAINTEXT)
+	if res1.Total != 6 || len(res1.Grams) != 6 || res1.N != 0 || err != nil {
+		t.Errorf("Got incorrect raw word table: %+v (%v)", res1, err)
+	}
+
+	res2, err := WordFrequency(PLAINTEXT, StripNonAlpha())
+	want2 := []Gram{{"THE", 3, 0.5}, {"CAT", 1, 1.0 / 6}, {"END", 1, 1.0 / 6}, {"HAT", 1, 1.0 / 6}}
+	if !slices.Equal(res2.Grams, want2) || err != nil {
+		t.Errorf("Got incorrect stripped word table: %+v (%v)", res2, err)
+	}
+
+	if _, err := WordFrequency("  ,. ", StripNonAlpha()); err == nil {
+		t.Errorf("Counted words in a text with no words")
+	}
+}
+
+func TestCompareFrequencies(t *testing.T) {
+	english, err := language.English.Frequencies(1)
+	if len(english) != ROMANWIDTH || err != nil {
+		t.Fatalf("Got incorrect English letter table: %v (%v)", english, err)
+	}
+
+	// English should look a lot more like English than the same text shifted over by 13
+	plaintext := sampleText(t, 2000)
+	ciphertext, _ := ROTX(plaintext, 13)
+	table1, _ := NGramFrequency(plaintext, 1)
+	table2, _ := NGramFrequency(ciphertext, 1)
+
+	res1, deviations := table1.Compare(english)
+	res2, _ := table2.Compare(english)
+	if res1 <= 0 || res1 * 10 > res2 || len(deviations) != ROMANWIDTH {
+		t.Errorf("English didn't compare closer to English than ROT13: %v vs %v", res1, res2)
+	}
+	for i := 1; i < len(deviations); i++ {
+		if absoluteError(deviations[i].Observed, deviations[i].Expected) > absoluteError(deviations[i - 1].Observed, deviations[i - 1].Expected) {
+			t.Errorf("Deviations are out of order: %+v before %+v", deviations[i - 1], deviations[i])
+		}
+	}
+
+	// Bigrams work the same way
+	bigrams, _ := language.English.Frequencies(2)
+	table3, _ := NGramFrequency(plaintext, 2)
+	table4, _ := NGramFrequency(ciphertext, 2)
+	res3, _ := table3.Compare(bigrams)
+	res4, _ := table4.Compare(bigrams)
+	if res3 * 10 > res4 {
+		t.Errorf("English bigrams didn't compare closer to English than ROT13: %v vs %v", res3, res4)
+	}
+}
@@ -0,0 +1,267 @@
+/** CIPHER IDENTIFICATION
+
+Before a ciphertext can be cracked, somebody has to work out what it was enciphered with, and the ciphertext gives away more than
+you'd think. Each family of ciphers leaves its own fingerprint on the statistics of the text:
+
+    - Transposition ciphers (rail fence, columnar) only move letters around, so the letters still come out with exactly the frequencies
+      of the language. E is still the most common letter, and there's still hardly any Z
+    - Monoalphabetic ciphers (ROTX, Caesar, Atbash, affine, keyphrase, MVPC) swap every letter for another one, but always the
+      same one. The frequencies get shuffled around between the letters, but the shape of them doesn't change: some letter is still
+      as common as E was, so the index of coincidence is still the language's
+    - Homophonic and book ciphers write letters as numbers, and give the common letters lots of numbers to choose from
+    - Polyalphabetic ciphers (Vigenere, Beaufort, Gronsfeld) swap each letter with a different alphabet depending on where it is,
+      which flattens the frequencies out. The alphabets repeat with the key though, so the letters a key length apart still have
+      the language's index of coincidence
+    - The one time pad never repeats, so its letters are as flat as random letters, however they're split up. Neither do autokey
+      and running keys, which come out nearly as flat
+
+IdentifyCipher measures all of that and turns it into a confidence for each family. The confidences come from a little decision
+tree: first, do the letter frequencies have the shape of the language or are they flat? If they have the shape, are they the
+language's own frequencies (transposition) or shuffled (monoalphabetic)? If they're flat, is there a period that brings the shape
+back (polyalphabetic) or not (aperiodic)? Each question is answered with a probability rather than a yes or no, by asking how
+much more likely the measurement is under one answer than the other, so the confidences always add up to 1 and get more certain as
+the ciphertext gets longer. Below a hundred or so letters, take them with a pinch of salt
+*/
+
+package ciphers
+
+import (
+	"errors"
+	"math"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// One family of ciphers that could have made a ciphertext
+type CipherGuess struct {
+	Family string
+	// Registry names of the ciphers in the family
+	Ciphers []string
+	// From 0 to 1. The confidences of every guess add up to 1
+	Confidence float64
+}
+
+// Everything IdentifyCipher measured about a ciphertext
+type CipherProfile struct {
+	// "letters", or "numbers" for ciphertexts made of whitespace separated numbers
+	Format string
+	// How many symbols (letters or numbers) the ciphertext has
+	Length int
+	// How many different symbols the ciphertext uses
+	Symbols int
+	// The chance that two symbols picked at random are the same one
+	IoC float64
+	// Shannon entropy in bits per symbol. Random letters come out at log2(26), about 4.7, and English at about 4.2
+	Entropy float64
+	// The most likely key length if it's a polyalphabetic cipher, or 1 if it doesn't look like one. 0 if the ciphertext isn't letters
+	Period int
+	// Most likely first
+	Guesses []CipherGuess
+}
+
+var cipherFamilies map[string][]string = map[string][]string{
+    "transposition":    {"columnar", "doublecolumnar", "railfence"},
+    "monoalphabetic":   {"affine", "atbash", "caesar", "keyphrase", "mvpc", "rotx"},
+    "homophonic":       {"book", "homophonic"},
+    "polyalphabetic":   {"beaufort", "gronsfeld", "variantbeaufort", "vigenere"},
+    "aperiodic":        {"autokey", "otp", "runningkey"},
+}
+
+// Index of coincidence and entropy of a list of symbols, and how many different ones there are
+func symbolStats[T comparable](text []T) (float64, float64, int) {
+    var counts map[T]int = make(map[T]int)
+    for _, cur := range text {
+        counts[cur]++
+    }
+
+    var sum int
+    var entropy float64
+    for _, count := range counts {
+        sum += count * (count - 1)
+        p := float64(count) / float64(len(text))
+        entropy -= p * math.Log2(p)
+    }
+    return float64(sum) / float64(len(text) * (len(text) - 1)), entropy, len(counts)
+}
+
+/* Every question in the decision tree comes down to the same measurement: is an index of coincidence the language's, or the flat
+1/26 of random letters? Measured over n letters, it wobbles around its true value with a variance of about 4(Σp³ - (Σp²)²)/n,
+where p is the frequency of each letter. Treating both answers as bell curves with that spread, the log of how much more likely the
+language is than random letters is a straight line in the measurement, and the probability is the logistic function of that
+*/
+type iocTest struct {
+    language, random, variance float64
+}
+
+func newIoCTest(probs []float64) iocTest {
+    var sum2, sum3 float64
+    for _, p := range probs {
+        sum2 += p * p
+        sum3 += p * p * p
+    }
+    return iocTest{language: sum2, random: 1 / float64(len(probs)), variance: 4 * (sum3 - sum2 * sum2)}
+}
+
+// Log of how much more likely ioc is for n letters of the language than for n random letters
+func (t iocTest) evidence(ioc float64, n int) float64 {
+    return (ioc - (t.language + t.random) / 2) * (t.language - t.random) * float64(n) / t.variance
+}
+
+func logistic(x float64) float64 {
+    return 1 / (1 + math.Exp(-x))
+}
+
+/* Telling a transposition from a monoalphabetic cipher means asking whether the letters are the language's own, or have been
+swapped around. The best any swap can do is put the most common ciphertext letter on the most common letter of the language, the
+second on the second and so on, so the question is how much better that is than leaving every letter where it is. For a
+transposition it's hardly any better (only letters with nearly the same frequency trade places), and for almost any real key it's
+a lot better. PLAINSLACK is how much better per letter, in nats, it can be before the letters look swapped
+*/
+const PLAINSLACK float64 = 0.2
+
+// Log-probability per letter gained by the best swap of the letters over none at all
+func swapGain(counts []int, probs []float64) float64 {
+    var total int
+    var direct float64
+    for i, count := range counts {
+        total += count
+        direct += float64(count) * math.Log(probs[i])
+    }
+
+    sortedcounts, sortedprobs := slices.Clone(counts), slices.Clone(probs)
+    slices.Sort(sortedcounts)
+    slices.Sort(sortedprobs)
+    var best float64
+    for i, count := range sortedcounts {
+        best += float64(count) * math.Log(sortedprobs[i])
+    }
+
+    return (best - direct) / float64(total)
+}
+
+// The language's letter frequencies over the alphabet, with letters the alphabet folds together added up. No letter is left at 0
+func alphabetFrequencies(o options) []float64 {
+    var probs []float64 = make([]float64, o.alphabet.Len())
+    freqs, _ := o.language.Frequencies(1)
+    for letter, freq := range freqs {
+        cur, valid := o.alphabet.Normalize([]rune(letter)[0])
+        if !valid {continue}
+        ind, _ := o.alphabet.Index(cur)
+        probs[ind] += freq
+    }
+
+    var total float64
+    for i := range probs {
+        probs[i] = max(probs[i], 1e-4)
+        total += probs[i]
+    }
+    for i := range probs {
+        probs[i] /= total
+    }
+    return probs
+}
+
+// Sort guesses most likely first, dropping the ones with no chance at all
+func rankGuesses(confidences map[string]float64) []CipherGuess {
+    var guesses []CipherGuess
+    for family, confidence := range confidences {
+        if confidence <= 0 {continue}
+        guesses = append(guesses, CipherGuess{Family: family, Ciphers: cipherFamilies[family], Confidence: confidence})
+    }
+    slices.SortFunc(guesses, func(a, b CipherGuess) int {
+        switch {
+        case a.Confidence > b.Confidence: return -1
+        case a.Confidence < b.Confidence: return 1
+        default: return strings.Compare(a.Family, b.Family)
+        }
+    })
+    return guesses
+}
+
+// Work out which family of ciphers a ciphertext was most likely made with. A ciphertext made entirely of whitespace separated numbers
+// is taken to be homophonic, and anything else is read as letters of the alphabet, ignoring everything that isn't one. Takes
+// WithAlphabet and WithLanguage, for ciphertexts that were enciphered in something other than English over A-Z
+func IdentifyCipher(ciphertext string, opts ...Option) (CipherProfile, error) {
+    o := getOptions(opts)
+    probs := alphabetFrequencies(o)
+    test := newIoCTest(probs)
+
+    fields := strings.Fields(ciphertext)
+    numbers := len(fields) > 0
+    for _, field := range fields {
+        numbers = numbers && strings.IndexFunc(field, func(r rune) bool {return !unicode.IsDigit(r)}) < 0
+    }
+
+    if numbers {
+        if len(fields) < 2 {return CipherProfile{}, errors.New("need at least 2 symbols")}
+        var profile CipherProfile = CipherProfile{Format: "numbers", Length: len(fields)}
+        profile.IoC, profile.Entropy, profile.Symbols = symbolStats(fields)
+
+        // A monoalphabetic cipher could have written its letters as numbers too, but it can't have more numbers than letters, and
+        // it keeps the shape of the language's frequencies where spreading letters over lots of symbols flattens them
+        var mono float64
+        if profile.Symbols <= len(probs) {mono = logistic(test.evidence(profile.IoC, profile.Length))}
+        profile.Guesses = rankGuesses(map[string]float64{"homophonic": 1 - mono, "monoalphabetic": mono})
+        return profile, nil
+    }
+
+    stripped, err := o.alphabet.Strip(ciphertext)
+    if err != nil {return CipherProfile{}, err}
+    var text []int
+    for _, cur := range stripped {
+        ind, _ := o.alphabet.Index(cur)
+        text = append(text, ind)
+    }
+    if len(text) < 2 {return CipherProfile{}, errors.New("need at least 2 letters")}
+
+    var profile CipherProfile = CipherProfile{Format: "letters", Length: len(text)}
+    profile.IoC, profile.Entropy, profile.Symbols = symbolStats(text)
+
+    // Does the text have the shape of the language at all?
+    shaped := logistic(test.evidence(profile.IoC, len(text)))
+
+    // If it does, are the letters where the language puts them?
+    var counts []int = make([]int, len(probs))
+    for _, cur := range text {
+        counts[cur]++
+    }
+    plain := logistic(float64(len(text)) * (PLAINSLACK - swapGain(counts, probs)))
+
+    /* If it doesn't, is there a period that brings it back? Columns a key length apart have the language's index of coincidence, and
+    averaging the columns together gives a measurement that wobbles just as much as one over the whole text. Every key length gets
+    the same prior chance, so the evidence for some period is the average of the evidence for each one. A period that's a multiple
+    of the key length works just as well as the key length itself, so the period reported is the shortest one that's close to the
+    best. Text that has the shape of the language already, or that no period helps, gets a period of 1
+    */
+    maxlen := max(1, min(20, len(text) / 8))
+    runes := []rune(stripped)
+    var iocs []float64 = make([]float64, maxlen + 1)
+    var periodic float64
+    for length := 1; length <= maxlen; length++ {
+        for _, col := range columns(runes, length) {
+            if len(col) >= 2 {iocs[length] += indexOfCoincidence(col) / float64(length)}
+        }
+        if length > 1 {periodic += math.Exp(min(test.evidence(iocs[length], len(text)), 700)) / float64(maxlen - 1)}
+    }
+    periodic /= 1 + periodic
+    profile.Period = 1
+    if shaped < 0.5 && periodic >= 0.5 {
+        best := slices.Max(iocs)
+        slack := max((test.language - test.random) / 4, 2 * math.Sqrt(test.variance / float64(len(text))))
+        for length := 1; length <= maxlen; length++ {
+            if iocs[length] >= best - slack {
+                profile.Period = length
+                break
+            }
+        }
+    }
+
+    profile.Guesses = rankGuesses(map[string]float64{
+        "transposition":    shaped * plain,
+        "monoalphabetic":   shaped * (1 - plain),
+        "polyalphabetic":   (1 - shaped) * periodic,
+        "aperiodic":        (1 - shaped) * (1 - periodic),
+    })
+    return profile, nil
+}
@@ -0,0 +1,64 @@
+package ciphers
+
+import (
+	"math"
+	"testing"
+)
+
+func TestIdentifyCipher(t *testing.T) {
+	plaintext := sampleText(t, 500)
+	railfence, _ := RailfenceEncrypt(plaintext)
+	keyphrase, _ := KeyphraseEncrypt(plaintext, "JULIUS CAESAR")
+	atbash, _ := Atbash(plaintext)
+	vigenere, _ := VigenereEncrypt(plaintext, "LEMON")
+	otp, _, _ := OTPEncrypt(plaintext)
+	homophonic, _, _ := HomophonicEncrypt(plaintext, 999)
+	autokey, _ := AutokeyEncrypt(plaintext, "QUEENLY")
+	runningkey, _ := RunningKeyEncrypt(plaintext, sampleText(t, 3000)[2000:])
+
+	tests := []struct {
+		name, ciphertext, family, format string
+		period int
+	}{
+		{"railfence", railfence, "transposition", "letters", 1},
+		{"keyphrase", keyphrase, "monoalphabetic", "letters", 1},
+		{"atbash", atbash, "monoalphabetic", "letters", 1},
+		{"vigenere", vigenere, "polyalphabetic", "letters", 5},
+		{"otp", otp, "aperiodic", "letters", 1},
+		{"autokey", autokey, "aperiodic", "letters", 1},
+		{"runningkey", runningkey, "aperiodic", "letters", 1},
+		{"homophonic", homophonic, "homophonic", "numbers", 0},
+	}
+
+	for _, test := range tests {
+		res, err := IdentifyCipher(test.ciphertext)
+		if err != nil {
+			t.Fatalf("Could not identify %v: %v", test.name, err)
+		}
+		if res.Guesses[0].Family != test.family || res.Guesses[0].Confidence < 0.9 {
+			t.Errorf("Got incorrect guess for %v: %+v", test.name, res.Guesses)
+		}
+		if res.Format != test.format || res.Period != test.period || res.Length != 500 {
+			t.Errorf("Got incorrect profile for %v: %+v", test.name, res)
+		}
+
+		var total float64
+		for _, guess := range res.Guesses {
+			total += guess.Confidence
+		}
+		if math.Abs(total - 1) > 1e-9 {
+			t.Errorf("Confidences for %v add up to %v", test.name, total)
+		}
+	}
+
+	// Random letters come out flatter than English
+	res1, _ := IdentifyCipher(plaintext)
+	res2, _ := IdentifyCipher(otp)
+	if res1.Entropy >= res2.Entropy || res2.Entropy > math.Log2(float64(ROMANWIDTH)) || res1.Symbols > ROMANWIDTH {
+		t.Errorf("Got incorrect entropies: %v for English, %v for the one time pad", res1.Entropy, res2.Entropy)
+	}
+
+	if _, err := IdentifyCipher("A"); err == nil {
+		t.Errorf("Identified a ciphertext with only one letter")
+	}
+}
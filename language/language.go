@@ -0,0 +1,335 @@
+/** LANGUAGE MODELS
+
+Every attack on a cipher comes down to asking "does this look like the language it was written in?" over and over again. A language
+model is what answers that question: a table of how likely every n-gram (run of n letters) is, counted from a big pile of text in
+that language. The more likely the n-grams of a text are, the more it looks like the language
+
+Models here cover n-grams from 1 to 4 letters long (unigrams through quadgrams) over the 26 letters A-Z. Text is cleaned up the same
+way for counting and for scoring: letters are upper-cased, accents come off (É is E, ß is SS, Æ is AE), and everything else is
+thrown away, so n-grams run straight across the gaps between words. Likelihoods are kept as natural log-probabilities and added up,
+so a higher (less negative) score is always better. N-grams that never turned up in the counting still get a small probability,
+otherwise one odd word would make a whole text score as impossible
+
+The models for English, French, German, Spanish and Latin are built in, and anything else can be trained from a text file with
+TrainFile. A model can be saved with Save and read back with Load, which is also how the built in ones are stored. This package
+doesn't know anything about ciphers, so it can be used on its own
+
+Where the built in models come from (the top of each file in data/ says the same thing):
+    - English: The Adventures of Sherlock Holmes, The Adventures of Tom Sawyer and Alice's Adventures in Wonderland, from Project
+      Gutenberg (eBooks #1661, #74 and #11). Public domain
+    - French, German and Spanish: the translated program messages of a Debian system plus the vim tutor. These are under the
+      licenses of the programs they came from, mostly the GPL, not public domain
+    - Latin: the passages in testdata/latin.txt (Caesar, Cicero, Virgil and friends). Public domain
+The first four are all counted from the same amount of text, about 836,000 letters. Latin only has about 11,600, so its quadgrams
+are a lot less trustworthy
+*/
+
+package language
+
+import (
+	"bufio"
+	_ "embed"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// The longest n-grams a model knows about
+const MAXN int = 4
+
+// The number of letters a model knows about, A-Z
+const WIDTH int = 26
+
+type Model struct {
+	Name string
+
+	// Where a built in model comes from. It isn't read until the model is first used
+	data string
+	once sync.Once
+
+	counts [MAXN]map[string]float64
+	totals [MAXN]float64
+	tables [MAXN][]float64
+}
+
+var (
+	//go:embed data/english.txt
+	englishData string
+	//go:embed data/french.txt
+	frenchData string
+	//go:embed data/german.txt
+	germanData string
+	//go:embed data/spanish.txt
+	spanishData string
+	//go:embed data/latin.txt
+	latinData string
+)
+
+var (
+	English *Model = &Model{Name: "english", data: englishData}
+	French *Model = &Model{Name: "french", data: frenchData}
+	German *Model = &Model{Name: "german", data: germanData}
+	Spanish *Model = &Model{Name: "spanish", data: spanishData}
+	Latin *Model = &Model{Name: "latin", data: latinData}
+)
+
+// The built in models, by name
+var Languages map[string]*Model = map[string]*Model{
+    "english": English,
+    "french":  French,
+    "german":  German,
+    "spanish": Spanish,
+    "latin":   Latin,
+}
+
+// Letters that are written as one or more other letters, on top of accents coming off (which unicode.SimpleFold can't do)
+var folds map[rune]string = map[rune]string{
+    'À': "A", 'Á': "A", 'Â': "A", 'Ã': "A", 'Ä': "A", 'Å': "A", 'Ā': "A", 'Ă': "A", 'Ą': "A",
+    'Ç': "C", 'Ć': "C", 'Č': "C",
+    'Ď': "D",
+    'È': "E", 'É': "E", 'Ê': "E", 'Ë': "E", 'Ē': "E", 'Ĕ': "E", 'Ė': "E", 'Ę': "E", 'Ě': "E",
+    'Ğ': "G",
+    'Ì': "I", 'Í': "I", 'Î': "I", 'Ï': "I", 'Ī': "I", 'Ĭ': "I", 'Į': "I", 'İ': "I",
+    'Ł': "L",
+    'Ñ': "N", 'Ń': "N", 'Ň': "N",
+    'Ò': "O", 'Ó': "O", 'Ô': "O", 'Õ': "O", 'Ö': "O", 'Ø': "O", 'Ō': "O", 'Ŏ': "O", 'Ő': "O",
+    'Ř': "R",
+    'Ś': "S", 'Ş': "S", 'Š': "S",
+    'Ť': "T",
+    'Ù': "U", 'Ú': "U", 'Û': "U", 'Ü': "U", 'Ū': "U", 'Ŭ': "U", 'Ů': "U", 'Ű': "U",
+    'Ý': "Y", 'Ÿ': "Y",
+    'Ź': "Z", 'Ż': "Z", 'Ž': "Z",
+    'Æ': "AE", 'Œ': "OE", 'ß': "SS", 'ẞ': "SS",
+}
+
+// Turn text into letter indices (A = 0), the way every model sees it
+func Letters(text string) []int {
+    var res []int = make([]int, 0, len(text))
+    for _, cur := range text {
+        cur = unicode.ToUpper(cur)
+        if cur >= 'A' && cur <= 'Z' {
+            res = append(res, int(cur - 'A'))
+            continue
+        }
+        for _, letter := range folds[cur] {
+            res = append(res, int(letter - 'A'))
+        }
+    }
+    return res
+}
+
+// The index of an n-gram in a table: its letters read as a base 26 number
+func gramIndex(letters []int) int {
+    var ind int
+    for _, letter := range letters {
+        ind = ind * WIDTH + letter
+    }
+    return ind
+}
+
+func gramText(ind, n int) string {
+    var res []byte = make([]byte, n)
+    for i := n - 1; i >= 0; i-- {
+        res[i] = byte('A' + ind % WIDTH)
+        ind /= WIDTH
+    }
+    return string(res)
+}
+
+// Build a model out of counts
+func newModel(name string, counts [MAXN]map[string]float64, totals [MAXN]float64) (*Model, error) {
+    var m *Model = &Model{Name: name, counts: counts, totals: totals}
+    m.once.Do(func() {})
+    if err := m.build(); err != nil {return nil, err}
+    return m, nil
+}
+
+// Work out the tables every score is looked up in from the counts
+func (m *Model) build() error {
+    for n := 1; n <= MAXN; n++ {
+        if m.totals[n - 1] <= 0 {return fmt.Errorf("no %v-grams counted", n)}
+
+        var table []float64 = make([]float64, int(math.Pow(float64(WIDTH), float64(n))))
+        floor := math.Log(0.01 / m.totals[n - 1])
+        for i := range table {
+            table[i] = floor
+        }
+        for gram, count := range m.counts[n - 1] {
+            table[gramIndex(Letters(gram))] = math.Log(count / m.totals[n - 1])
+        }
+        m.tables[n - 1] = table
+    }
+
+    return nil
+}
+
+// Read in a built in model the first time it's used. The data was written by Save, so a mistake in it is a bug
+func (m *Model) load() {
+    m.once.Do(func() {
+        loaded, err := Load(m.Name, strings.NewReader(m.data))
+        if err != nil {panic("could not read " + m.Name + " model: " + err.Error())}
+        m.counts, m.totals, m.tables = loaded.counts, loaded.totals, loaded.tables
+        m.data = ""
+    })
+}
+
+// Log-probability of every n-gram, indexed by its letters as a base 26 number (A = 0). This is what solvers use to score text
+// without going through strings. The table is shared, so don't change it
+func (m *Model) Table(n int) ([]float64, error) {
+    if n <= 0 || n > MAXN {return nil, fmt.Errorf("only have n-grams from 1 to %v letters long", MAXN)}
+    m.load()
+    return m.tables[n - 1], nil
+}
+
+// Log-probability of a single n-gram, from 1 to 4 letters long once it's cleaned up
+func (m *Model) LogProb(gram string) (float64, error) {
+    letters := Letters(gram)
+    table, err := m.Table(len(letters))
+    if err != nil {return 0, err}
+    return table[gramIndex(letters)], nil
+}
+
+// How much text looks like the language, scored on its n-grams. Every n-gram in the text counts, overlapping ones included. Text
+// with fewer than n letters scores 0
+func (m *Model) ScoreN(text string, n int) (float64, error) {
+    table, err := m.Table(n)
+    if err != nil {return 0, err}
+
+    var score float64
+    letters := Letters(text)
+    for i := 0; i + n <= len(letters); i++ {
+        score += table[gramIndex(letters[i:i + n])]
+    }
+    return score, nil
+}
+
+// How much text looks like the language, scored on quadgrams. Text shorter than 4 letters is scored on the longest n-grams that fit
+func (m *Model) Score(text string) float64 {
+    score, _ := m.ScoreN(text, max(1, min(MAXN, len(Letters(text)))))
+    return score
+}
+
+// How often every n-gram turned up, as a fraction of all of them. N-grams that never turned up are left out. The map is new every
+// time, so it's safe to change
+func (m *Model) Frequencies(n int) (map[string]float64, error) {
+    if n <= 0 || n > MAXN {return nil, fmt.Errorf("only have n-grams from 1 to %v letters long", MAXN)}
+    m.load()
+
+    var res map[string]float64 = make(map[string]float64, len(m.counts[n - 1]))
+    for gram, count := range m.counts[n - 1] {
+        res[gram] = count / m.totals[n - 1]
+    }
+    return res, nil
+}
+
+// The letters A-Z from most to least common
+func (m *Model) Order() string {
+    freqs, _ := m.Frequencies(1)
+    var letters []byte = []byte("ABCDEFGHIJKLMNOPQRSTUVWXYZ")
+    slices.SortStableFunc(letters, func(a, b byte) int {
+        fa, fb := freqs[string(a)], freqs[string(b)]
+        switch {
+        case fa > fb: return -1
+        case fa < fb: return 1
+        default: return 0
+        }
+    })
+    return string(letters)
+}
+
+// The chance that two letters picked at random from some text in the language are the same letter. For completely random letters
+// it's 1/26, about 0.0385, and for English it's about 0.066
+func (m *Model) IndexOfCoincidence() float64 {
+    freqs, _ := m.Frequencies(1)
+    var ioc float64
+    for _, freq := range freqs {
+        ioc += freq * freq
+    }
+    return ioc
+}
+
+/* Models are stored as plain text, so they're easy to look at and to diff. Every line is an n-gram and the number of times it was
+counted, most common first within each length. Lines starting with # are comments, except for "# total N COUNT", which gives the
+total number of n-grams of length N that were counted (including any that were pruned and aren't written down):
+
+    # total 1 9010415
+    # total 2 9010414
+    ...
+    E 1130273
+    T 868516
+    ...
+    TH 268123
+*/
+
+// Write the model out in the format Load reads. Comments are written first, one per line
+func (m *Model) Save(w io.Writer, comments ...string) error {
+    m.load()
+    var buf *bufio.Writer = bufio.NewWriter(w)
+    write := func(format string, args ...any) {fmt.Fprintf(buf, format, args...)}
+
+    for _, comment := range comments {
+        write("# %v\n", comment)
+    }
+    for n := 1; n <= MAXN; n++ {
+        write("# total %v %v\n", n, strconv.FormatFloat(m.totals[n - 1], 'f', -1, 64))
+    }
+    for n := 1; n <= MAXN; n++ {
+        var grams []string = make([]string, 0, len(m.counts[n - 1]))
+        for gram := range m.counts[n - 1] {
+            grams = append(grams, gram)
+        }
+        slices.SortFunc(grams, func(a, b string) int {
+            ca, cb := m.counts[n - 1][a], m.counts[n - 1][b]
+            switch {
+            case ca > cb: return -1
+            case ca < cb: return 1
+            default: return strings.Compare(a, b)
+            }
+        })
+
+        for _, gram := range grams {
+            write("%v %v\n", gram, strconv.FormatFloat(m.counts[n - 1][gram], 'f', -1, 64))
+        }
+    }
+
+    return buf.Flush()
+}
+
+// Read a model written by Save
+func Load(name string, r io.Reader) (*Model, error) {
+    var counts [MAXN]map[string]float64
+    var totals [MAXN]float64
+    for i := range counts {
+        counts[i] = make(map[string]float64)
+    }
+
+    var scanner *bufio.Scanner = bufio.NewScanner(r)
+    for scanner.Scan() {
+        line := scanner.Text()
+        fields := strings.Fields(line)
+        if len(fields) <= 0 {continue}
+        if fields[0] == "#" {
+            if len(fields) != 4 || fields[1] != "total" {continue}
+            n, err := strconv.Atoi(fields[2])
+            if err != nil || n <= 0 || n > MAXN {return nil, errors.New("could not read total \"" + line + "\"")}
+            total, err := strconv.ParseFloat(fields[3], 64)
+            if err != nil {return nil, errors.New("could not read total \"" + line + "\"")}
+            totals[n - 1] = total
+            continue
+        }
+
+        if len(fields) != 2 || len(fields[0]) > MAXN || len(Letters(fields[0])) != len(fields[0]) {return nil, errors.New("could not read line \"" + line + "\"")}
+        count, err := strconv.ParseFloat(fields[1], 64)
+        if err != nil {return nil, errors.New("could not read count for \"" + fields[0] + "\"")}
+        counts[len(fields[0]) - 1][strings.ToUpper(fields[0])] = count
+    }
+    if err := scanner.Err(); err != nil {return nil, err}
+
+    return newModel(name, counts, totals)
+}
@@ -0,0 +1,111 @@
+package language
+
+import (
+	"math"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestLetters(t *testing.T) {
+	res := Letters("Æsop's Straße, déjà vu!")
+	var want []int
+	for _, cur := range "AESOPSSTRASSEDEJAVU" {
+		want = append(want, int(cur - 'A'))
+	}
+	if !slices.Equal(res, want) {
+		t.Errorf("Got incorrect letters: %v", res)
+	}
+}
+
+func TestScore(t *testing.T) {
+	// A sentence from each language should look the most like its own language
+	samples := map[*Model]string{
+		English: "The quick brown fox jumps over the lazy dog while the farmer watches from the window",
+		French:  "Le renard brun saute par-dessus le chien paresseux pendant que le fermier regarde par la fenêtre",
+		German:  "Der schnelle braune Fuchs springt über den faulen Hund, während der Bauer aus dem Fenster schaut",
+		Spanish: "El zorro marrón salta sobre el perro perezoso mientras el granjero mira desde la ventana",
+		Latin:   "Vulpes celeris super canem pigrum salit, dum agricola e fenestra spectat et ridet",
+	}
+
+	for lang, sample := range samples {
+		for other := range samples {
+			if other == lang {
+				continue
+			}
+			if lang.Score(sample) <= other.Score(sample) {
+				t.Errorf("%v sample looks more like %v: %v vs %v", lang.Name, other.Name, lang.Score(sample), other.Score(sample))
+			}
+		}
+	}
+
+	// Short texts fall back on shorter n-grams instead of scoring nothing
+	res1, _ := English.LogProb("E")
+	if res2 := English.Score("e!"); res2 != res1 || res1 >= 0 {
+		t.Errorf("Got incorrect score for a single letter: %v vs %v", res2, res1)
+	}
+	if _, err := English.ScoreN("ABCDE", 5); err == nil {
+		t.Errorf("Scored with n-grams the model doesn't have")
+	}
+
+	res3 := English.IndexOfCoincidence()
+	if math.Abs(res3 - 0.066) > 0.005 {
+		t.Errorf("English has an unusual index of coincidence: %v", res3)
+	}
+	if res4 := English.Order(); !strings.HasPrefix(res4, "ET") {
+		t.Errorf("Got incorrect letter order for English: %v", res4)
+	}
+}
+
+func TestTrain(t *testing.T) {
+	res1, err := TrainFile("latin", "testdata/latin.txt")
+	if err != nil {
+		t.Fatalf("Could not train model: %v", err)
+	}
+
+	// The start of Tacitus' Annals, which isn't in the training file. A model that learned anything about Latin should like it
+	// better than the modern languages do
+	const SAMPLE string = "Urbem Romam a principio reges habuere; libertatem et consulatum L. Brutus instituit. Dictaturae ad tempus " +
+		"sumebantur; neque decemviralis potestas ultra biennium, neque tribunorum militum consulare ius diu valuit."
+	for _, other := range []*Model{English, French, German, Spanish} {
+		if res1.Score(SAMPLE) <= other.Score(SAMPLE) {
+			t.Errorf("Trained Latin model scored unseen Latin worse than %v: %v vs %v", other.Name, res1.Score(SAMPLE), other.Score(SAMPLE))
+		}
+	}
+
+	// Saving and loading shouldn't change anything either
+	var saved strings.Builder
+	if err := res1.Save(&saved, "A comment", "over two lines"); err != nil {
+		t.Fatalf("Could not save model: %v", err)
+	}
+	if !strings.HasPrefix(saved.String(), "# A comment\n# over two lines\n# total 1 ") {
+		t.Errorf("Saved model has the wrong header: %.60q", saved.String())
+	}
+	res2, err := Load("latin", strings.NewReader(saved.String()))
+	if err != nil || res2.Score(SAMPLE) != res1.Score(SAMPLE) {
+		t.Errorf("Loaded model doesn't match the saved one (%v)", err)
+	}
+
+	// Pruning only forgets the rare long n-grams, and doesn't change the probability of the rest
+	before, _ := res1.Frequencies(4)
+	bigrams, _ := res1.Frequencies(2)
+	res1.Prune(2)
+	after, _ := res1.Frequencies(4)
+	after2, _ := res1.Frequencies(2)
+	if len(after) >= len(before) || len(after2) != len(bigrams) || after["TQUE"] != before["TQUE"] {
+		t.Errorf("Pruning went wrong: %v quadgrams before, %v after", len(before), len(after))
+	}
+
+	f, err := os.CreateTemp(t.TempDir(), "empty")
+	if err != nil {
+		t.Fatalf("Could not make a temporary file: %v", err)
+	}
+	f.Close()
+	if _, err := TrainFile("empty", f.Name()); err == nil {
+		t.Errorf("Trained a model on an empty file")
+	}
+	if _, err := Load("broken", strings.NewReader("THE one\n")); err == nil {
+		t.Errorf("Loaded a model with a broken count")
+	}
+}
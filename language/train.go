@@ -0,0 +1,54 @@
+package language
+
+import (
+	"errors"
+	"io"
+	"os"
+)
+
+// Count the n-grams of text (already turned into letters) into a new model
+func count(name string, letters []int) (*Model, error) {
+    if len(letters) < MAXN {return nil, errors.New("need at least 4 letters to train a model")}
+    var counts [MAXN]map[string]float64
+    var totals [MAXN]float64
+
+    for n := 1; n <= MAXN; n++ {
+        counts[n - 1] = make(map[string]float64)
+        for i := 0; i + n <= len(letters); i++ {
+            counts[n - 1][gramText(gramIndex(letters[i:i + n]), n)]++
+        }
+        totals[n - 1] = float64(len(letters) - n + 1)
+    }
+
+    return newModel(name, counts, totals)
+}
+
+// Train a model on everything r has to give. Any text works, as long as there's a lot of it: a few hundred thousand letters gives
+// good quadgrams, and a few thousand is enough for single letters
+func Train(name string, r io.Reader) (*Model, error) {
+    text, err := io.ReadAll(r)
+    if err != nil {return nil, err}
+    return count(name, Letters(string(text)))
+}
+
+// Train a model on the contents of a file
+func TrainFile(name, path string) (*Model, error) {
+    f, err := os.Open(path)
+    if err != nil {return nil, err}
+    defer f.Close()
+
+    return Train(name, f)
+}
+
+// Forget the n-grams of 3 or more letters that were seen fewer than min times, which is most of them for a big corpus. The totals
+// stay the same, so everything left keeps the probability it had. Only use this on a trained model, not a built in one, and not
+// while anything else is using the model
+func (m *Model) Prune(min float64) {
+    m.load()
+    for n := 3; n <= MAXN; n++ {
+        for gram, count := range m.counts[n - 1] {
+            if count < min {delete(m.counts[n - 1], gram)}
+        }
+    }
+    m.build()
+}
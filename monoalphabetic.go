@@ -8,11 +8,13 @@ how to make an MSC more secure to freq.an. is an interesting thought experiment,
 still ultimately insecure
 
 Ciphers implemented in this file:
-    - "Rail Fence" Transposition Cipher (Page 8)
+    - "Rail Fence" Transposition Cipher (Page 8), on 2 rails or any number
+    - Columnar and Double Columnar Transposition
     - Mlecchita-vikalpa Pairing Cipher (Page 9)
     - Caesar / ROTX Cipher (Page 10)
     - Simple Keyphrase Cipher (Page 13)
     - Atbash (Page 26)
+    - Affine Cipher
     - Homophonic Substitution Cipher (Page 52)
     - Book Cipher (Page 90)
 */
@@ -25,21 +27,13 @@ import (
 	"math"
 	"math/rand/v2"
 	"slices"
+	"strconv"
 	"strings"
+	"unicode"
 )
 
 func stripnonalpha(text string) (string, error) {
-    if len(text) <= 0 {return "", errors.New("given empty string")}
-    var res string
-
-    for _, cur := range text {
-        if(cur >= 'a' && cur <= 'z') {cur -= 'a' - 'A'
-        } else if(cur < 'A' || cur > 'Z') {continue}
-        
-        res += string(cur)
-    }
-
-    return res, nil
+    return RomanAlphabet.Strip(text)
 }
 
 /* The "Rail Fence" Cipher is a simple transposition cipher, meaning it simply rearranges the order of the letters contained in the
@@ -74,47 +68,224 @@ plaintext. Here is an example from The Code Book: (page 8)
 */
 
 // Encipher a plaintext via the Rail Fence Transposition Cipher
-func RailfenceEncrypt(plaintext string) (string, error) {
-    if(len(plaintext) <= 0) {return "", errors.New("given empty string")}
-    plaintext, err := stripnonalpha(plaintext)
-    if err != nil {return "", err}
+func RailfenceEncrypt(plaintext string, opts ...Option) (string, error) {
+    return RailfenceEncryptN(plaintext, 2, 0, opts...)
+}
+
+// Decipher a ciphertext via the Rail Fence Transposition Cipher
+func RailfenceDecrypt(ciphertext string, opts ...Option) (string, error) {
+    return RailfenceDecryptN(ciphertext, 2, 0, opts...)
+}
+
+/* The two rail fence is the simplest case of a more general cipher: write the plaintext in a zig-zag down and up across any number
+of rails, then read the rails off one after the other. With 3 rails:
+
+    Plaintext:  WEAREDISCOVEREDFLEEATONCE
+
+        W . . . E . . . C . . . R . . . L . . . T . . . E
+        . E . R . D . S . O . E . E . F . E . A . O . C .
+        . . A . . . I . . . V . . . D . . . E . . . N . .
+
+    Ciphertext: WECRLTEERDSOEEFEAOCAIVDEN
+
+The zig-zag repeats every 2 * (rails - 1) letters, and the offset says how far into that the plaintext starts, as if that many
+letters had already been written. An offset of 1 on 3 rails starts on the middle rail heading down, and an offset of 3 starts on
+the middle rail heading back up. The two rail version above is 2 rails with no offset
+
+Deciphering means working out how many letters each rail got, which is the same as running the zig-zag again and seeing where every
+letter would land
+*/
+
+// Which rail the ith letter goes on
+func railOf(i, rails, offset int) int {
+    if rails <= 1 {return 0}
+
+    cycle := 2 * (rails - 1)
+    pos := (i + offset) % cycle
+    if pos >= rails {pos = cycle - pos}
+    return pos
+}
 
-    var halves [2]string
-    var chars []string = strings.Split(plaintext, "")
+// Which rail every letter of a text of length letters goes on
+func railPath(length, rails, offset int) []int {
+    var path []int = make([]int, length)
+    for i := range path {
+        path[i] = railOf(i, rails, offset)
+    }
+    return path
+}
 
-    for i := 0; i < len(plaintext); i++ {
-        halves[i%2] += chars[i]
+// The order letters are read off the rails in: the plaintext letter that ends up first in the ciphertext, then second...
+func railOrder(length, rails, offset int) []int {
+    path := railPath(length, rails, offset)
+    var order []int = make([]int, length)
+    for i := range order {
+        order[i] = i
     }
+    slices.SortStableFunc(order, func(a, b int) int {return path[a] - path[b]})
+    return order
+}
 
-    return halves[0] + halves[1], nil
+func checkRails(rails, offset int) error {
+    if rails <= 0 {return errors.New("need at least 1 rail")}
+    if offset < 0 || (rails > 1 && offset >= 2 * (rails - 1)) || (rails == 1 && offset != 0) {
+        return fmt.Errorf("offset for %v rails has to be from 0 to %v", rails, max(0, 2 * (rails - 1) - 1))
+    }
+    return nil
 }
 
-// Decipher a ciphertext via the Rail Fence Transposition Cipher
-func RailfenceDecrypt(ciphertext string) (string, error) {
-    if(len(ciphertext) <= 0) {return "", errors.New("given empty string")}
-    ciphertext, err := stripnonalpha(ciphertext)
+// Encipher a plaintext via the Rail Fence Transposition Cipher on any number of rails, starting offset letters into the zig-zag
+func RailfenceEncryptN(plaintext string, rails, offset int, opts ...Option) (string, error) {
+    if len(plaintext) <= 0 {return "", errors.New("given empty string")}
+    if err := checkRails(rails, offset); err != nil {return "", err}
+    o := getOptions(opts)
+    stripped, err := o.alphabet.Strip(plaintext)
     if err != nil {return "", err}
 
-    var res string
-    var halves [2][]rune
-    var h1l int = len(ciphertext)/2
-    if(len(ciphertext)%2==1) {h1l++}
+    letters := []rune(stripped)
+    var res []rune = make([]rune, 0, len(letters))
+    for _, ind := range railOrder(len(letters), rails, offset) {
+        res = append(res, letters[ind])
+    }
+    return string(res), nil
+}
 
-    halves[0] = []rune(ciphertext)[0:h1l]
-    halves[1] = []rune(ciphertext)[h1l:]
-    
-    for i, j, t := 0, 0, 0; t < len(ciphertext); t++ {
-        if(t%2==0) {
-            res += string(halves[0][i])
-            i++
-        } else {
-            res += string(halves[1][j])
-            j++
+// Decipher a ciphertext via the Rail Fence Transposition Cipher on any number of rails, starting offset letters into the zig-zag
+func RailfenceDecryptN(ciphertext string, rails, offset int, opts ...Option) (string, error) {
+    if len(ciphertext) <= 0 {return "", errors.New("given empty string")}
+    if err := checkRails(rails, offset); err != nil {return "", err}
+    o := getOptions(opts)
+    stripped, err := o.alphabet.Strip(ciphertext)
+    if err != nil {return "", err}
+
+    letters := []rune(stripped)
+    var res []rune = make([]rune, len(letters))
+    for i, ind := range railOrder(len(letters), rails, offset) {
+        res[ind] = letters[i]
+    }
+    return string(res), nil
+}
+
+
+/* Columnar transposition writes the plaintext out in rows under a keyword, one letter per column, then reads the columns off top to
+bottom in the alphabetical order of the keyword's letters. Repeated letters in the keyword go left to right. With ZEBRAS:
+
+    Plaintext:  WE ARE DISCOVERED. FLEE AT ONCE
+
+        Z E B R A S
+        6 3 2 4 1 5
+        -----------
+        W E A R E D
+        I S C O V E
+        R E D F L E
+        E A T O N C
+        E
+
+    Ciphertext: EVLNACDTESEAROFODEECWIREE
+
+When the last row isn't full (incomplete columns), the columns on the left are one letter longer than the rest, and deciphering
+means working that out from the length of the ciphertext before the columns can be put back. Filling the last row out with nulls
+(WithPadding) makes every column the same length (complete columns), which is easier to do by hand but gives away the key length,
+since the length of the ciphertext has to be a multiple of it
+
+Transposing the ciphertext again under a second keyword gives double transposition, which was the standard field cipher of both
+world wars. A single columnar transposition can be put back together column by column by looking for pairs of letters that fit, but
+after two the letters that started out next to each other are scattered all over the place
+*/
+
+// The order the columns are read off in: the column under the alphabetically first letter of the keyword, then the second...
+func columnOrder(keyword string, o options) ([]int, error) {
+    keyword, err := o.alphabet.Strip(keyword)
+    if err != nil {return nil, err}
+    letters := []rune(keyword)
+    if len(letters) <= 0 {return nil, errors.New("keyword has no letters in it")}
+
+    var order []int = make([]int, len(letters))
+    for i := range order {
+        order[i] = i
+    }
+    slices.SortStableFunc(order, func(a, b int) int {
+        inda, _ := o.alphabet.Index(letters[a])
+        indb, _ := o.alphabet.Index(letters[b])
+        return inda - indb
+    })
+    return order, nil
+}
+
+// Read text off in columns under a keyword, or put it back from them
+func columnarProcess(text []rune, order []int, decrypt bool) []rune {
+    width := len(order)
+    var res []rune = make([]rune, len(text))
+
+    var pos int
+    for _, col := range order {
+        for ind := col; ind < len(text); ind += width {
+            if decrypt {
+                res[ind] = text[pos]
+            } else {
+                res[pos] = text[ind]
+            }
+            pos++
         }
-        // Yeah this is not great but it works
     }
+    return res
+}
 
-    return res, nil
+// Strip text and fill out its last row with nulls, if there should be any
+func columnarText(text string, width int, o options) ([]rune, error) {
+    stripped, err := o.alphabet.Strip(text)
+    if err != nil {return nil, err}
+    letters := []rune(stripped)
+    if o.padding == 0 {return letters, nil}
+
+    null, valid := o.alphabet.Normalize(o.padding)
+    if !valid {return nil, fmt.Errorf("null %q is not in the alphabet", o.padding)}
+    for len(letters) % width != 0 {
+        letters = append(letters, null)
+    }
+    return letters, nil
+}
+
+// Encipher a plaintext via columnar transposition under keyword. Takes WithPadding
+func ColumnarEncrypt(plaintext, keyword string, opts ...Option) (string, error) {
+    if len(plaintext) <= 0 || len(keyword) <= 0 {return "", errors.New("given empty string")}
+    o := getOptions(opts)
+    order, err := columnOrder(keyword, o)
+    if err != nil {return "", err}
+    letters, err := columnarText(plaintext, len(order), o)
+    if err != nil {return "", err}
+
+    return string(columnarProcess(letters, order, false)), nil
+}
+
+// Decipher a ciphertext via columnar transposition under keyword. Complete and incomplete columns both work without being told
+// which is which, since the length of the ciphertext says how many letters each column has
+func ColumnarDecrypt(ciphertext, keyword string, opts ...Option) (string, error) {
+    if len(ciphertext) <= 0 || len(keyword) <= 0 {return "", errors.New("given empty string")}
+    o := getOptions(opts)
+    order, err := columnOrder(keyword, o)
+    if err != nil {return "", err}
+    stripped, err := o.alphabet.Strip(ciphertext)
+    if err != nil {return "", err}
+
+    return string(columnarProcess([]rune(stripped), order, true)), nil
+}
+
+// Encipher a plaintext via double columnar transposition: under first, then under second. WithPadding only fills out the rows
+// under the first keyword; padding them out under the second as well would need nulls in the middle of the message
+func DoubleColumnarEncrypt(plaintext, first, second string, opts ...Option) (string, error) {
+    if len(second) <= 0 {return "", errors.New("given empty string")}
+    once, err := ColumnarEncrypt(plaintext, first, opts...)
+    if err != nil {return "", err}
+    return ColumnarEncrypt(once, second, append(slices.Clone(opts), WithPadding(0))...)
+}
+
+// Decipher a ciphertext via double columnar transposition, with the same two keywords in the same order it was enciphered with
+func DoubleColumnarDecrypt(ciphertext, first, second string, opts ...Option) (string, error) {
+    if len(first) <= 0 {return "", errors.New("given empty string")}
+    once, err := ColumnarDecrypt(ciphertext, second, opts...)
+    if err != nil {return "", err}
+    return ColumnarDecrypt(once, first, opts...)
 }
 
 
@@ -142,11 +313,24 @@ func keymapProcess(text string, key map[rune]rune) (string, error) {
     return string(inter), nil
 }
 
-func MVPCEncrypt(plaintext string) (string, map[rune]rune, error) {
-    plaintext, err := stripnonalpha(plaintext)
-    if err != nil {return "", nil, err}
+// Run text through an MVPC key, respecting the options. Letters the key doesn't cover are left alone
+func mvpcProcess(text string, key map[rune]rune, o options) (string, error) {
+    if len(text) <= 0 || len(key) <= 0 {return "", errors.New("given an empty string")}
+
+    res := o.substitute(text, func(cur rune) rune {
+        if pair, exists := key[cur]; exists {return pair}
+        return cur
+    })
+    if len(res) <= 0 {return "", errors.New("given text with no letters")}
+
+    return res, nil
+}
 
-    var key map[rune]rune = make(map[rune]rune, 26)
+func MVPCEncrypt(plaintext string, opts ...Option) (string, map[rune]rune, error) {
+    if len(plaintext) <= 0 {return "", nil, errors.New("given empty string")}
+    o := getOptions(opts)
+
+    var key map[rune]rune = make(map[rune]rune, o.alphabet.Len())
 
     // Populate key
         // Get 2 letters at random
@@ -155,9 +339,9 @@ func MVPCEncrypt(plaintext string) (string, map[rune]rune, error) {
 
     // Note: I'm not going to bother with cryptographically secure randomness as there's no point with a cipher so simple
 
-    var letters []rune = []rune(ROMANALPHA)
+    var letters []rune = []rune(o.alphabet.String())
     var lp [2]rune
-    for ; len(letters) > 0; {
+    for ; len(letters) > 1; {
         for i, randi := 0, 0; i < 2; i++ {
             randi = rand.IntN(len(letters))
             lp[i] = letters[randi]
@@ -168,13 +352,17 @@ func MVPCEncrypt(plaintext string) (string, map[rune]rune, error) {
         key[lp[0]] = lp[1]
         key[lp[1]] = lp[0]
     }
+    // Alphabets with an odd number of letters leave one letter without a partner, so it has to stand for itself
+    if len(letters) == 1 {key[letters[0]] = letters[0]}
 
-    ciphertext, err := keymapProcess(plaintext, key)
+    ciphertext, err := mvpcProcess(plaintext, key, o)
     if(err != nil) {return "", nil, err}
     return ciphertext, key, nil
 }
 
-func MVPCDecrypt(ciphertext string, key map[rune]rune) (string, error) {
+func MVPCDecrypt(ciphertext string, key map[rune]rune, opts ...Option) (string, error) {
+    o := getOptions(opts)
+    if o.preserve {return mvpcProcess(ciphertext, key, o)}
     return keymapProcess(ciphertext, key)
 }
 
@@ -200,29 +388,26 @@ Here's the example in the book:
         YHQL YLGL YLFL
 */
 
-func ROTX(text string, offset rune) (string, error) {
-    if len(text) <= 0                   {return "", errors.New("given empty string")}
-    if offset % rune(ROMANWIDTH) == 0   {return "", errors.New("given offset that would not meaningfully encrypt message (" + string(offset) + " % " + fmt.Sprintf("%d", ROMANWIDTH) + " == 0)")}
-    text, err := stripnonalpha(text)
-    if err != nil {return "", err}
+func ROTX(text string, offset rune, opts ...Option) (string, error) {
+    o := getOptions(opts)
+    var width rune = rune(o.alphabet.Len())
+    if len(text) <= 0          {return "", errors.New("given empty string")}
+    if offset % width == 0     {return "", errors.New("given offset that would not meaningfully encrypt message (" + fmt.Sprint(offset) + " % " + fmt.Sprintf("%d", width) + " == 0)")}
 
-    var res string
-
-    for ; offset < 0; offset += rune(ROMANWIDTH) {}
-    for _, cur := range text {
-        if cur < 'A' || cur > 'Z' {continue}
-        res += string(((cur - 'A' + offset) % rune(ROMANWIDTH)) + 'A') 
-    }   
+    res := o.substitute(text, func(cur rune) rune {
+        ind, _ := o.alphabet.Index(cur)
+        return o.alphabet.Rune(ind + int(offset))
+    })
 
     return res, nil
 }
 
-func CaesarEncrypt(text string) (string, error) {
-    return ROTX(text, 3)
+func CaesarEncrypt(text string, opts ...Option) (string, error) {
+    return ROTX(text, 3, opts...)
 }
 
-func CaesarDecrypt(text string) (string, error) {
-    return ROTX(text, -3)
+func CaesarDecrypt(text string, opts ...Option) (string, error) {
+    return ROTX(text, -3, opts...)
 }
 
 
@@ -247,19 +432,12 @@ plaintext letters accordingly. Here's the book's example:
         JULISCAERTVWXYZBDFGHKMNOPQ
 */
 
-func keyphraseProcess(text, keyphrase string, mode bool) (string, error) {
-    if len(text) <= 0 || len(keyphrase) <= 0 {return "", errors.New("given empty string")}
-    text, err := stripnonalpha(text)
-    if err != nil {return "", err}
-    keyphrase, err = stripnonalpha(keyphrase)
-    if err != nil {return "", err}
-
-    var key map[rune]rune = make(map[rune]rune, 26)
+// Drop every letter that's already turned up earlier on, keeping the rest in order. Both the keyphrase cipher and the Playfair square
+// build their alphabets this way
+func uniqueLetters(kpstr []rune) []rune {
     var set GSet[rune] = NewGSet[rune]()
-
-    // The last element of keyphrase is, or rather contains, the index of where the romanalpha slice should start
-        // Ex: last letter is 'R', 'R' - 'A' is the index of 'R' in ROMANALPHA
-    var kpstr []rune = []rune(keyphrase + ROMANALPHA[[]rune(keyphrase)[len(keyphrase) - 1] - 'A' + 1:] + ROMANALPHA[:[]rune(keyphrase)[len(keyphrase) - 1] - 'A'])
+    var res []rune
+    kpstr = slices.Clone(kpstr)
 
     // For each letter in the alphabet:
         // Check to see if the letter has already been mapped
@@ -267,16 +445,37 @@ func keyphraseProcess(text, keyphrase string, mode bool) (string, error) {
             // If so, move to the next letter in the mapping
                 // If keyphrase has been consumed, continue with the rest of the alphabet from the end of the phrase
 
-    for ind := 0; len(kpstr) > 0; {
+    for len(kpstr) > 0 {
         if !set.check(kpstr[0]) {
             set.add(kpstr[0])
-            key[[]rune(ROMANALPHA)[ind]] = kpstr[0]
-            ind++
+            res = append(res, kpstr[0])
         }
 
         kpstr = slices.Delete(kpstr, 0, 1)
     }
 
+    return res
+}
+
+func keyphraseProcess(text, keyphrase string, mode bool, o options) (string, error) {
+    if len(text) <= 0 || len(keyphrase) <= 0 {return "", errors.New("given empty string")}
+    keyphrase, err := o.alphabet.Strip(keyphrase)
+    if err != nil {return "", err}
+    if len(keyphrase) <= 0 {return "", errors.New("keyphrase has no letters in it")}
+
+    var key map[rune]rune = make(map[rune]rune, o.alphabet.Len())
+
+    // The last element of keyphrase is, or rather contains, the index of where the alphabet slice should start
+        // Ex: last letter is 'R', 'R' is at index 17 of A-Z
+    var letters []rune = []rune(o.alphabet.String())
+    var kprunes []rune = []rune(keyphrase)
+    last, _ := o.alphabet.Index(kprunes[len(kprunes) - 1])
+    var kpstr []rune = slices.Concat(kprunes, letters[last + 1:], letters[:last])
+
+    for ind, cur := range uniqueLetters(kpstr) {
+        key[letters[ind]] = cur
+    }
+
     // (Decryption) Invert the key map so that the current ABCD... -> XXXX... map becomes XXXX.... -> ABCD...
     if mode {
         key, err = invertmap(key)
@@ -284,39 +483,134 @@ func keyphraseProcess(text, keyphrase string, mode bool) (string, error) {
     }
 
     // Process data
-    res, err := keymapProcess(text, key)
-    if len(res) <= 0 || err != nil {
+    res := o.substitute(text, func(cur rune) rune {return key[cur]})
+    if len(res) <= 0 {
         return "", errors.New("could not process text")
     }
 
     return res, nil
 }
 
-func KeyphraseEncrypt(text, keyphrase string) (string, error) {
-    return keyphraseProcess(text, keyphrase, false)
+func KeyphraseEncrypt(text, keyphrase string, opts ...Option) (string, error) {
+    return keyphraseProcess(text, keyphrase, false, getOptions(opts))
 }
 
-func KeyphraseDecrypt(text, keyphrase string) (string, error) {
-    return keyphraseProcess(text, keyphrase, true)
+func KeyphraseDecrypt(text, keyphrase string, opts ...Option) (string, error) {
+    return keyphraseProcess(text, keyphrase, true, getOptions(opts))
 }
 
 
 /* Atbash is an interesting cipher due to it's origin, that being the Bible (old testament specifically). It's quite simple, as
 all it does is replace letters with their "opposites". A becomes Z, B becomes Y, C becomes X, and so on */
 
-func Atbash(text string) (string, error) {
+func Atbash(text string, opts ...Option) (string, error) {
     if len(text) <= 0 {return "", errors.New("given empty string")}
-    text, err := stripnonalpha(text)
-    if err != nil {return "", err}
-    var res string
+    o := getOptions(opts)
 
-    for _, cur := range text {
-        res += string('Z' - cur + 'A')
+    res := o.substitute(text, func(cur rune) rune {
+        ind, _ := o.alphabet.Index(cur)
+        return o.alphabet.Rune(o.alphabet.Len() - 1 - ind)
+    })
+
+    return res, nil
+}
+
+/* ROTX and Atbash turn out to be two cases of the same thing. Number the letters from 0 (A = 0, B = 1 ... Z = 25), and ROTX adds
+the offset to every letter, while Atbash takes every letter away from 25. The affine cipher does both at once: multiply the letter
+by one number, then add another, wrapping around at the end of the alphabet
+
+    E(x) = a * x + b (mod 26)
+
+So Caesar is a = 1, b = 3, and Atbash is a = -1, b = -1 (or a = 25, b = 25, which is the same thing mod 26). Any other multiplier
+mixes the alphabet up in a way neither of them can:
+
+    Key: a = 5, b = 8
+        ABCDEFGHIJKLMNOPQRSTUVWXYZ
+        INSXCHMRWBGLQVAFKPUZEJOTYD
+
+    Plaintext:
+        AFFINE CIPHER
+    Ciphertext:
+        IHHWVC SWFRCP
+
+The catch is in the multiplier. Multiplying by 2 sends A and N both to A, so there'd be no telling which one was meant, and the same
+goes for any multiplier that shares a factor with 26. That only leaves the 12 multipliers that don't (1, 3, 5, 7, 9, 11, 15, 17,
+19, 21, 23 and 25). Decrypting undoes the addition, then multiplies by the modular inverse of a, the number that takes a back to 1
+mod 26 (for 5 that's 21, since 5 * 21 = 105 = 4 * 26 + 1)
+
+With only 12 multipliers and 26 offsets, there are just 312 keys to try
+*/
+
+// A key for the affine cipher, E(x) = Multiplier * x + Offset. Both are taken modulo the length of the alphabet, so negative
+// numbers work the way you'd expect
+type AffineKey struct {
+    Multiplier, Offset int
+}
+
+// The affine keys that do the same thing as Caesar and Atbash, over any alphabet
+var (
+    CaesarAffineKey AffineKey = AffineKey{Multiplier: 1, Offset: 3}
+    AtbashAffineKey AffineKey = AffineKey{Multiplier: -1, Offset: -1}
+)
+
+// The affine key that does the same thing as ROTX with offset
+func ROTXAffineKey(offset int) AffineKey {
+    return AffineKey{Multiplier: 1, Offset: offset}
+}
+
+func gcd(a, b int) int {
+    for b != 0 {
+        a, b = b, a % b
+    }
+    return a
+}
+
+// Reduce x modulo width into the range 0 to width - 1, including negative numbers
+func modulo(x, width int) int {
+    return ((x % width) + width) % width
+}
+
+// The number that a has to be multiplied by to get 1 mod width, found with the extended Euclidean algorithm. a has to be coprime
+// with width
+func modInverse(a, width int) (int, error) {
+    a = modulo(a, width)
+    if gcd(a, width) != 1 {return 0, errors.New("multiplier " + fmt.Sprint(a) + " shares a factor with the alphabet's length (" + fmt.Sprint(width) + "), so it can't be undone")}
+
+    // Keep r = s * a (mod width) true the whole way down to r = 1
+    var r, newr int = width, a
+    var s, news int = 0, 1
+    for newr != 0 {
+        quotient := r / newr
+        r, newr = newr, r - quotient * newr
+        s, news = news, s - quotient * news
     }
+    return modulo(s, width), nil
+}
+
+func affineProcess(text string, key AffineKey, decrypt bool, o options) (string, error) {
+    if len(text) <= 0 {return "", errors.New("given empty string")}
+    width := o.alphabet.Len()
+    inverse, err := modInverse(key.Multiplier, width)
+    if err != nil {return "", err}
+
+    res := o.substitute(text, func(cur rune) rune {
+        ind, _ := o.alphabet.Index(cur)
+        if decrypt {return o.alphabet.Rune(modulo(inverse * (ind - key.Offset), width))}
+        return o.alphabet.Rune(modulo(key.Multiplier * ind + key.Offset, width))
+    })
+    if len(res) <= 0 {return "", errors.New("could not strip non-alphanumeric characters from text")}
 
     return res, nil
 }
 
+func AffineEncrypt(plaintext string, key AffineKey, opts ...Option) (string, error) {
+    return affineProcess(plaintext, key, false, getOptions(opts))
+}
+
+func AffineDecrypt(ciphertext string, key AffineKey, opts ...Option) (string, error) {
+    return affineProcess(ciphertext, key, true, getOptions(opts))
+}
+
 /* The Homophonic Substitution Cipher was the military's solution to encryption in an age before the widespread adoption of the 
 Vigenere Cipher. Enciphering via Vigenere was considered too complicated / costly, but a straightforward substitution cipher was
 considered too weak, so cryptographers needed an intermediary option. They settled on the homophonic substitution cipher, a cipher
@@ -401,4 +695,113 @@ func HomophonicDecrypt(ciphertext string, key map[string]rune) (string, error) {
     var temp []string = strings.Split(ciphertext, " ")
     plaintext, err := automap(temp, key)
     return string(plaintext), err
-}
\ No newline at end of file
+}
+
+
+/* The Book Cipher uses a piece of text both parties already have as the key. Every word of the key text is numbered, and each letter
+of the plaintext is replaced with the number of some word starting with that letter. The most famous example is the second Beale
+cipher, which was enciphered with the Declaration of Independence: 115 is "instituted", 73 is "hold", 24 is "another" and so on,
+giving "I HAV(E) ...". (Page 90)
+
+Like the homophonic cipher, a common letter has lots of words to choose from, so a good encipherer picks a different one every time
+and frequency analysis has nothing to hold on to. Unlike the homophonic cipher, the key never has to be written down anywhere; anyone
+who knows which book to look in can read the message
+
+Exactly how the words get numbered is up to whoever set up the cipher, so the numbering is described by a BookScheme. The zero value
+is the scheme Beale used: words are numbered from 1, and a hyphenated word like "self-evident" counts as two words
+*/
+
+type BookScheme struct {
+    // Number the first word 0 instead of 1
+    ZeroBased bool
+    // Count a hyphenated word as a single word
+    JoinHyphens bool
+    // Number every letter of the key text instead of every word
+    Letters bool
+}
+
+// Number the key text according to the scheme. Returns every number each letter could be written as, and the letter each number
+// stands for. A "word" with no letters in it (a year, say) still gets a number, it just can't stand for anything
+func bookIndex(keytext string, scheme BookScheme, o options) (map[rune][]int, map[int]rune, error) {
+    if len(keytext) <= 0 {return nil, nil, errors.New("given empty keytext")}
+    var candidates map[rune][]int = make(map[rune][]int)
+    var numbers map[int]rune = make(map[int]rune)
+    var n int = 1
+    if scheme.ZeroBased {n = 0}
+
+    if scheme.Letters {
+        for _, cur := range keytext {
+            letter, ok := o.alphabet.Normalize(cur)
+            if !ok {continue}
+
+            candidates[letter] = append(candidates[letter], n)
+            numbers[n] = letter
+            n++
+        }
+    } else {
+        words := strings.FieldsFunc(keytext, func(cur rune) bool {
+            return unicode.IsSpace(cur) || (!scheme.JoinHyphens && cur == '-')
+        })
+
+        for _, word := range words {
+            for _, cur := range word {
+                letter, ok := o.alphabet.Normalize(cur)
+                if !ok {continue}
+
+                candidates[letter] = append(candidates[letter], n)
+                numbers[n] = letter
+                break
+            }
+            n++
+        }
+    }
+
+    if len(numbers) <= 0 {return nil, nil, errors.New("keytext has no letters in it")}
+    return candidates, numbers, nil
+}
+
+// Encipher the plaintext as a space separated list of numbers, picking a random word out of the key text for every letter. Letters
+// the key text has no words for can't be enciphered, and give an error
+func BookEncrypt(plaintext, keytext string, scheme BookScheme, opts ...Option) (string, error) {
+    o := getOptions(opts)
+    text, err := o.alphabet.Strip(plaintext)
+    if err != nil {return "", err}
+    if len(text) <= 0 {return "", errors.New("plaintext has no letters in it")}
+
+    candidates, _, err := bookIndex(keytext, scheme, o)
+    if err != nil {return "", err}
+
+    var res []string = make([]string, 0, len(text))
+    for _, cur := range text {
+        choices := candidates[cur]
+        if len(choices) <= 0 {return "", errors.New("keytext has no words starting with " + string(cur))}
+        res = append(res, fmt.Sprint(choices[rand.IntN(len(choices))]))
+    }
+
+    return strings.Join(res, " "), nil
+}
+
+// Decipher a list of numbers. Anything that isn't a digit counts as a separator, so both "115 73 24" and Beale's "115, 73, 24"
+// work. A number past the end of the key text, or one for a word with no letters, gives an error
+func BookDecrypt(ciphertext, keytext string, scheme BookScheme, opts ...Option) (string, error) {
+    if len(ciphertext) <= 0 {return "", errors.New("given empty string")}
+    o := getOptions(opts)
+
+    _, numbers, err := bookIndex(keytext, scheme, o)
+    if err != nil {return "", err}
+
+    fields := strings.FieldsFunc(ciphertext, func(cur rune) bool {return cur < '0' || cur > '9'})
+    if len(fields) <= 0 {return "", errors.New("ciphertext has no numbers in it")}
+
+    var res []rune = make([]rune, 0, len(fields))
+    for _, field := range fields {
+        n, err := strconv.Atoi(field)
+        if err != nil {return "", err}
+
+        letter, exists := numbers[n]
+        if !exists {return "", errors.New("keytext has no word numbered " + field)}
+        res = append(res, letter)
+    }
+
+    return string(res), nil
+}
@@ -1,6 +1,8 @@
 package ciphers
 
 import (
+	"os"
+	"strings"
 	"testing"
 )
 
@@ -17,6 +19,132 @@ func TestRailfence(t *testing.T) {
 	if(res != PLAINTEXT || err != nil) {
 		t.Errorf("Got incorrect string during decryption: %v (%v)", res, err)
 	}
+
+	// The two rail version is just 2 rails with no offset
+	res, err = RailfenceEncryptN(PLAINTEXT, 2, 0)
+	if(res != CIPHERTEXT || err != nil) {
+		t.Errorf("Got incorrect string during 2 rail encryption: %v (%v)", res, err)
+	}
+}
+
+func TestRailfenceN(t *testing.T) {
+	const PLAINTEXT string = "We are discovered, flee at once!"
+
+	tests := []struct {
+		rails, offset int
+		ciphertext string
+	}{
+		{3, 0, "WECRLTEERDSOEEFEAOCAIVDEN"},
+		{3, 1, "RSEFACWAEICVRDLETNEEDOEEO"},
+		{4, 3, "ROFOAECVDLTNEDSEEEACWIREE"},
+		{1, 0, "WEAREDISCOVEREDFLEEATONCE"},
+		{30, 0, "WEAREDISCOVEREDFLEEATONCE"},
+	}
+
+	for _, test := range tests {
+		res1, err := RailfenceEncryptN(PLAINTEXT, test.rails, test.offset)
+		if res1 != test.ciphertext || err != nil {
+			t.Errorf("Got incorrect string from %v rail encryption with offset %v: %v (%v)", test.rails, test.offset, res1, err)
+		}
+
+		res2, err := RailfenceDecryptN(test.ciphertext, test.rails, test.offset)
+		if res2 != "WEAREDISCOVEREDFLEEATONCE" || err != nil {
+			t.Errorf("Got incorrect string from %v rail decryption with offset %v: %v (%v)", test.rails, test.offset, res2, err)
+		}
+	}
+
+	// Every key has to undo itself, whatever the length of the text
+	for length := 1; length <= 12; length++ {
+		plaintext := "ABCDEFGHIJKL"[:length]
+		for rails := 1; rails <= 6; rails++ {
+			for offset := 0; offset < max(1, 2 * (rails - 1)); offset++ {
+				ciphertext, _ := RailfenceEncryptN(plaintext, rails, offset)
+				res, err := RailfenceDecryptN(ciphertext, rails, offset)
+				if res != plaintext || err != nil {
+					t.Errorf("%v rails with offset %v didn't decrypt %v: %v (%v)", rails, offset, plaintext, res, err)
+				}
+			}
+		}
+	}
+
+	for _, key := range [][2]int{{0, 0}, {3, 4}, {3, -1}, {1, 1}} {
+		if _, err := RailfenceEncryptN(PLAINTEXT, key[0], key[1]); err == nil {
+			t.Errorf("Encrypted with %v rails and offset %v", key[0], key[1])
+		}
+	}
+}
+
+func TestColumnar(t *testing.T) {
+	const PLAINTEXT string = "We are discovered. Flee at once!"
+
+	res1, err := ColumnarEncrypt(PLAINTEXT, "ZEBRAS")
+	if res1 != "EVLNACDTESEAROFODEECWIREE" || err != nil {
+		t.Errorf("Got incorrect string from columnar encryption: %v (%v)", res1, err)
+	}
+	res2, err := ColumnarDecrypt(res1, "zebras")
+	if res2 != "WEAREDISCOVEREDFLEEATONCE" || err != nil {
+		t.Errorf("Got incorrect string from columnar decryption: %v (%v)", res2, err)
+	}
+
+	// Padding fills out the last row, and the nulls come back out on the end
+	res3, err := ColumnarEncrypt(PLAINTEXT, "ZEBRAS", WithPadding('x'))
+	if res3 != "EVLNXACDTXESEAXROFOXDEECXWIREE" || err != nil {
+		t.Errorf("Got incorrect string from padded columnar encryption: %v (%v)", res3, err)
+	}
+	res4, err := ColumnarDecrypt(res3, "ZEBRAS")
+	if res4 != "WEAREDISCOVEREDFLEEATONCEXXXXX" || err != nil {
+		t.Errorf("Got incorrect string from padded columnar decryption: %v (%v)", res4, err)
+	}
+
+	// Repeated letters in the keyword are read left to right
+	res5, _ := ColumnarEncrypt("ABCDEF", "BAB")
+	if res5 != "BEADCF" {
+		t.Errorf("Got incorrect string from columnar encryption with repeated letters: %v", res5)
+	}
+
+	// Every length of text has to come back, however ragged the last row is
+	for length := 1; length <= 20; length++ {
+		plaintext := "THYSECRETISTHYPRISON"[:length]
+		ciphertext, _ := ColumnarEncrypt(plaintext, "SECRET")
+		res, err := ColumnarDecrypt(ciphertext, "SECRET")
+		if res != plaintext || err != nil {
+			t.Errorf("Columnar transposition didn't decrypt %v: %v (%v)", plaintext, res, err)
+		}
+	}
+
+	if _, err := ColumnarEncrypt(PLAINTEXT, "1234"); err == nil {
+		t.Errorf("Encrypted with a keyword with no letters")
+	}
+	if _, err := ColumnarEncrypt(PLAINTEXT, "ZEBRAS", WithPadding('1')); err == nil {
+		t.Errorf("Padded with a null that isn't a letter")
+	}
+}
+
+func TestDoubleColumnar(t *testing.T) {
+	const PLAINTEXT string = "THYSECRETISTHYPRISONERIFTHOULETITGOTHOUARTAPRISONERTOIT"
+
+	// Double transposition is just two transpositions one after the other
+	once, _ := ColumnarEncrypt(PLAINTEXT, "ZEBRAS")
+	twice, _ := ColumnarEncrypt(once, "STRIPE")
+	res1, err := DoubleColumnarEncrypt(PLAINTEXT, "ZEBRAS", "STRIPE")
+	if res1 != twice || err != nil {
+		t.Errorf("Got incorrect string from double columnar encryption: %v (%v)", res1, err)
+	}
+	res2, err := DoubleColumnarDecrypt(res1, "ZEBRAS", "STRIPE")
+	if res2 != PLAINTEXT || err != nil {
+		t.Errorf("Got incorrect string from double columnar decryption: %v (%v)", res2, err)
+	}
+
+	// Only the first transposition gets padded, so the nulls end up on the end of the plaintext and nowhere else
+	res3, _ := DoubleColumnarEncrypt(PLAINTEXT, "ZEBRAS", "STRIPE", WithPadding('Q'))
+	res4, err := DoubleColumnarDecrypt(res3, "ZEBRAS", "STRIPE")
+	if len(res3) != 60 || res4 != PLAINTEXT + "QQQQQ" || err != nil {
+		t.Errorf("Got incorrect string from padded double columnar decryption: %v (%v)", res4, err)
+	}
+
+	if _, err := DoubleColumnarEncrypt(PLAINTEXT, "ZEBRAS", ""); err == nil {
+		t.Errorf("Encrypted with an empty second keyword")
+	}
 }
 
 func TestMVPC(t *testing.T) {
@@ -115,6 +243,51 @@ func TestAtbash(t *testing.T) {
 	}
 }
 
+func TestAffine(t *testing.T) {
+	key := AffineKey{Multiplier: 5, Offset: 8}
+	res1, err := AffineEncrypt("Affine cipher", key, PreserveFormat())
+	if res1 != "Ihhwvc swfrcp" || err != nil {
+		t.Errorf("Got incorrect string from affine encryption: %v (%v)", res1, err)
+	}
+	res2, err := AffineDecrypt(res1, key, PreserveFormat())
+	if res2 != "Affine cipher" || err != nil {
+		t.Errorf("Got incorrect string from affine decryption: %v (%v)", res2, err)
+	}
+
+	// Caesar, ROTX and Atbash are all affine ciphers
+	const PLAINTEXT string = "SOILOOKEDANDSAWAWHITEHORSE"
+	caesar, _ := CaesarEncrypt(PLAINTEXT)
+	rot, _ := ROTX(PLAINTEXT, 14)
+	atbash, _ := Atbash(PLAINTEXT)
+	for _, test := range []struct {
+		key AffineKey
+		want string
+	}{
+		{CaesarAffineKey, caesar},
+		{ROTXAffineKey(14), rot},
+		{ROTXAffineKey(-12), rot},
+		{AtbashAffineKey, atbash},
+		{AffineKey{Multiplier: 25, Offset: 25}, atbash},
+	} {
+		if res, err := AffineEncrypt(PLAINTEXT, test.key); res != test.want || err != nil {
+			t.Errorf("Affine key %+v doesn't match: %v vs %v (%v)", test.key, res, test.want, err)
+		}
+	}
+
+	// Multipliers that share a factor with the alphabet's length can't be undone
+	for _, multiplier := range []int{0, 2, 13, 26} {
+		if _, err := AffineEncrypt(PLAINTEXT, AffineKey{Multiplier: multiplier, Offset: 1}); err == nil {
+			t.Errorf("Encrypted with multiplier %v", multiplier)
+		}
+	}
+	if _, err := AffineEncrypt(PLAINTEXT, AffineKey{Multiplier: 3, Offset: 1}, WithAlphabet(AlphanumericAlphabet)); err == nil {
+		t.Errorf("Encrypted with a multiplier of 3 over 36 letters")
+	}
+	if _, err := AffineDecrypt("", key); err == nil {
+		t.Errorf("Decrypted an empty string")
+	}
+}
+
 func Test_stripnonalpha(t *testing.T) {
 	const UNSTRIPPED string = "This is a string!"
 	const STRIPPED string	= "THISISASTRING"
@@ -199,4 +372,84 @@ func TestHomophonic(t *testing.T) {
 	if res2 != PLAINTEXT || err != nil {
 		t.Errorf("Got incorrect string from Homophonic decryption: %v %v (%v)", res2, key1, err)
 	}
-}
\ No newline at end of file
+}
+
+func TestBook(t *testing.T) {
+	keytext, err := os.ReadFile("testdata/declaration.txt")
+	if err != nil {
+		t.Fatalf("Could not read key text: %v", err)
+	}
+	const PLAINTEXT string = "Meet me at the old mill at midnight"
+
+	schemes := []BookScheme{{}, {ZeroBased: true}, {JoinHyphens: true}, {Letters: true}}
+	for _, scheme := range schemes {
+		res1, err := BookEncrypt(PLAINTEXT, string(keytext), scheme)
+		if len(res1) <= 0 || err != nil {
+			t.Errorf("Got incorrect string from Book encryption with %+v: %v (%v)", scheme, res1, err)
+		}
+		res2, err := BookDecrypt(res1, string(keytext), scheme)
+		if res2 != "MEETMEATTHEOLDMILLATMIDNIGHT" || err != nil {
+			t.Errorf("Got incorrect string from Book decryption with %+v: %v (%v)", scheme, res2, err)
+		}
+	}
+
+	// The numbering scheme matters: "self-evident" is words 78 and 79 the way Beale counted, but only word 78 if hyphens join
+	res3, err := BookDecrypt("78 79 80", string(keytext), BookScheme{})
+	if res3 != "SET" || err != nil {
+		t.Errorf("Got incorrect string from Book decryption: %v (%v)", res3, err)
+	}
+	res4, err := BookDecrypt("78 79 80", string(keytext), BookScheme{JoinHyphens: true})
+	if res4 != "STA" || err != nil {
+		t.Errorf("Got incorrect string from Book decryption: %v (%v)", res4, err)
+	}
+	res5, err := BookDecrypt("78 79 80", string(keytext), BookScheme{ZeroBased: true})
+	if res5 != "ETA" || err != nil {
+		t.Errorf("Got incorrect string from Book decryption: %v (%v)", res5, err)
+	}
+
+	if _, err := BookEncrypt("XYLOPHONE", string(keytext), BookScheme{}); err == nil {
+		t.Errorf("Enciphered a letter no word in the key text starts with")
+	}
+	if _, err := BookDecrypt("1 2 5000", string(keytext), BookScheme{}); err == nil {
+		t.Errorf("Deciphered a number past the end of the key text")
+	}
+}
+
+// The second Beale cipher, enciphered with the Declaration of Independence. Beale was not a careful counter (he skipped about ten
+// words just after word 480, and drifted by a word or two in a few other places), so a literal decode comes out full of the same
+// typos every published decode has. What matters is that it's unmistakably the right message
+func TestBookBeale(t *testing.T) {
+	keytext, err := os.ReadFile("testdata/declaration.txt")
+	if err != nil {
+		t.Fatalf("Could not read key text: %v", err)
+	}
+	ciphertext, err := os.ReadFile("testdata/beale2.txt")
+	if err != nil {
+		t.Fatalf("Could not read ciphertext: %v", err)
+	}
+
+	expected, _ := stripnonalpha("I have deposited in the county of Bedford, about four miles from Buford's, in an excavation or vault, six " +
+		"feet below the surface of the ground, the following articles, belonging jointly to the parties whose names are given in " +
+		"number three, herewith: The first deposit consisted of ten hundred and fourteen pounds of gold, and thirty-eight hundred " +
+		"and twelve pounds of silver, deposited Nov. eighteen nineteen. The second was made Dec. eighteen twenty-one, and consisted " +
+		"of nineteen hundred and seven pounds of gold, and twelve hundred and eighty-eight of silver; also jewels, obtained in St. " +
+		"Louis in exchange to save transportation, and valued at thirteen thousand dollars. The above is securely packed in iron " +
+		"pots, with iron covers. The vault is roughly lined with stone, and the vessels rest on solid stone, and are covered with " +
+		"others. Paper number one describes the exact locality of the vault, so that no difficulty will be had in finding it.")
+
+	res, err := BookDecrypt(string(ciphertext), string(keytext), BookScheme{})
+	if len(res) != len(expected) || err != nil {
+		t.Fatalf("Got incorrect string from decoding Beale #2: %v (%v)", res, err)
+	}
+	if !strings.HasPrefix(res, "IHAIEDEPOSOTEDINTHECOPNTTOLBEDOORTABOUPFOURMILESFROMBULORDS") {
+		t.Errorf("Got incorrect start of Beale #2: %v", res)
+	}
+
+	var matches int
+	for i := range res {
+		if res[i] == expected[i] {matches++}
+	}
+	if accuracy := float64(matches) / float64(len(expected)); accuracy < 0.75 {
+		t.Errorf("Only %.1f%% of Beale #2 decoded correctly: %v", accuracy * 100, res)
+	}
+}
@@ -0,0 +1,122 @@
+package ciphers
+
+import (
+	"math/rand/v2"
+	"unicode"
+
+	"github.com/realconebob/ciphers/language"
+)
+
+// Options change how a cipher works without every variation needing its own function. Ciphers ignore options that don't apply
+// to them, so the same list of options can be handed to every cipher in a program
+type Option func(*options)
+
+type options struct {
+	alphabet *Alphabet
+	preserve bool
+	strip bool
+	padding rune
+	tableau Tableau
+	restarts int
+	iterations int
+	seed uint64
+	seeded bool
+	language *language.Model
+}
+
+func getOptions(opts []Option) options {
+    var o options = options{alphabet: RomanAlphabet, language: language.English, tableau: VigenereTableau}
+    for _, opt := range opts {
+        if opt != nil {opt(&o)}
+    }
+    if o.alphabet == nil {o.alphabet = RomanAlphabet}
+    if o.language == nil {o.language = language.English}
+    if o.tableau.text != 1 && o.tableau.text != -1 {o.tableau = VigenereTableau}
+
+    return o
+}
+
+// Use a different alphabet than A-Z
+func WithAlphabet(alphabet *Alphabet) Option {
+    return func(o *options) {o.alphabet = alphabet}
+}
+
+// Only encipher the letters, and pass everything else (spaces, punctuation, digits...) through untouched. Letters keep their case,
+// so the ciphertext still looks like the original text and decrypting it gives the original back exactly. The exception is a letter
+// the alphabet folds into another one (J in the Latin alphabet, say), which comes back as the letter it was folded into
+//
+// This only makes sense for substitution ciphers (ROTX, Caesar, Atbash, Keyphrase, MVPC, Vigenere and the one time pad); anything that
+// moves letters around ignores it
+func PreserveFormat() Option {
+    return func(o *options) {o.preserve = true}
+}
+
+// Count only the letters of the alphabet, normalized the way the alphabet normalizes them, and drop everything else before counting.
+// Only the frequency tables (NGramFrequency, WordFrequency) look at this
+func StripNonAlpha() Option {
+    return func(o *options) {o.strip = true}
+}
+
+// Fill out the last row of a columnar transposition with null, so that every column comes out the same length. The nulls are
+// still there after decrypting, on the end of the plaintext, where they're easy enough to spot. null has to be a letter of the
+// alphabet. Only the columnar transpositions, Hill (which fills out its last block, with X by default) and Playfair (which uses
+// it as the filler between doubled letters, also X by default) look at this
+func WithPadding(null rune) Option {
+    return func(o *options) {o.padding = null}
+}
+
+// The rule the Vigenere family of ciphers combines the text and the key with (see Tableau). Vigenere, autokey, running key, the one
+// time pad, Gronsfeld and CrackVigenere all look at this, and they use VigenereTableau when it isn't given
+func WithTableau(tableau Tableau) Option {
+    return func(o *options) {o.tableau = tableau}
+}
+
+// How many times a randomized solver starts over from scratch. More restarts take longer, but are less likely to get stuck on a
+// wrong answer that happens to look good. Solvers pick their own default when this isn't given
+func WithRestarts(restarts int) Option {
+    return func(o *options) {o.restarts = restarts}
+}
+
+// How many keys a randomized solver tries on each run before it settles on the best one. More iterations take longer, but give it
+// more of a chance to find its way out of a wrong answer. Solvers pick their own default when this isn't given
+func WithIterations(iterations int) Option {
+    return func(o *options) {o.iterations = iterations}
+}
+
+// Seed the random numbers a solver uses, so that running it again on the same ciphertext gives exactly the same answer. Without
+// this every run is seeded differently
+func WithSeed(seed uint64) Option {
+    return func(o *options) {o.seed, o.seeded = seed, true}
+}
+
+// The language a solver expects the plaintext to be in. Solvers assume English when this isn't given. The built in languages are in
+// language.Languages, and language.TrainFile makes a model for anything else
+func WithLanguage(lang *language.Model) Option {
+    return func(o *options) {o.language = lang}
+}
+
+// A random number generator for a solver, seeded the way the options say
+func (o options) rng() *rand.Rand {
+    if o.seeded {return rand.New(rand.NewPCG(o.seed, o.seed))}
+    return rand.New(rand.NewPCG(rand.Uint64(), rand.Uint64()))
+}
+
+// Run each letter of text through f. Normally this strips the text the same way Alphabet.Strip does, but with PreserveFormat
+// everything that isn't a letter is kept where it was, and lowercase letters go back to being lowercase
+func (o options) substitute(text string, f func(letter rune) rune) string {
+    var res []rune = make([]rune, 0, len(text))
+
+    for _, cur := range text {
+        letter, ok := o.alphabet.Normalize(cur)
+        if !ok {
+            if o.preserve {res = append(res, cur)}
+            continue
+        }
+
+        letter = f(letter)
+        if o.preserve && unicode.IsLower(cur) {letter = unicode.ToLower(letter)}
+        res = append(res, letter)
+    }
+
+    return string(res)
+}
@@ -0,0 +1,77 @@
+package ciphers
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestPreserveFormat(t *testing.T) {
+	const PLAINTEXT string = "Veni, vidi, vici! (Or so Caesar wrote in 47 BC.)"
+
+	res1, err := CaesarEncrypt("VENI, VIDI, VICI", PreserveFormat())
+	if res1 != "YHQL, YLGL, YLFL" || err != nil {
+		t.Errorf("Got incorrect string from formatted Caesar encryption: %v (%v)", res1, err)
+	}
+	res2, err := CaesarDecrypt(res1, PreserveFormat())
+	if res2 != "VENI, VIDI, VICI" || err != nil {
+		t.Errorf("Got incorrect string from formatted Caesar decryption: %v (%v)", res2, err)
+	}
+
+	res3, err := ROTX(PLAINTEXT, 13, PreserveFormat())
+	if res3 != "Irav, ivqv, ivpv! (Be fb Pnrfne jebgr va 47 OP.)" || err != nil {
+		t.Errorf("Got incorrect string from formatted ROTX encryption: %v (%v)", res3, err)
+	}
+
+	res4, err := Atbash("Wizard of Oz", PreserveFormat())
+	if res4 != "Draziw lu La" || err != nil {
+		t.Errorf("Got incorrect string from formatted Atbash encryption: %v (%v)", res4, err)
+	}
+
+	// The key only moves along on letters, so the letters come out the same as they would without the formatting
+	res5, err := VigenereEncrypt(PLAINTEXT, "KEY", PreserveFormat())
+	if err != nil {
+		t.Errorf("Got error from formatted Vigenere encryption: %v", err)
+	}
+	stripped1, _ := stripnonalpha(res5)
+	stripped2, _ := VigenereEncrypt(PLAINTEXT, "KEY")
+	if stripped1 != stripped2 {
+		t.Errorf("Formatted Vigenere encryption used the key differently: %v vs %v", stripped1, stripped2)
+	}
+
+	// Every cipher has to give back exactly what it was given
+	mvpc := &MVPCCipher{Options: []Option{PreserveFormat()}}
+	all := []Cipher{
+		&ROTXCipher{Offset: 5, Options: []Option{PreserveFormat()}},
+		&CaesarCipher{Options: []Option{PreserveFormat()}},
+		&AtbashCipher{Options: []Option{PreserveFormat()}},
+		&KeyphraseCipher{Keyphrase: "Julius Caesar", Options: []Option{PreserveFormat()}},
+		mvpc,
+		&VigenereCipher{Keytext: "ANDYETEMANCIPATEDITMUSTBE", Options: []Option{PreserveFormat()}},
+		&OTPCipher{Options: []Option{PreserveFormat()}},
+	}
+
+	for _, c := range all {
+		res6, err := c.Encrypt(PLAINTEXT)
+		if len(res6) != len(PLAINTEXT) || err != nil {
+			t.Errorf("Got incorrect string from formatted %v encryption: %v (%v)", c.Name(), res6, err)
+		}
+
+		res7, err := c.Decrypt(res6)
+		if res7 != PLAINTEXT || err != nil {
+			t.Errorf("Got incorrect string from formatted %v decryption: %v (%v)", c.Name(), res7, err)
+		}
+	}
+
+	// Streams have to keep the formatting the same way, even split into tiny chunks
+	var res8 bytes.Buffer
+	w, err := NewKeymapWriter(&res8, mvpc.Pairs, PreserveFormat())
+	if err != nil {
+		t.Fatalf("Could not make keymap writer: %v", err)
+	}
+	writeChunks(t, w, PLAINTEXT, 3)
+
+	res9, err := MVPCDecrypt(res8.String(), mvpc.Pairs, PreserveFormat())
+	if res9 != PLAINTEXT || err != nil {
+		t.Errorf("Got incorrect string from formatted keymap stream: %v (%v)", res9, err)
+	}
+}
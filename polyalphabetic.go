@@ -10,19 +10,25 @@ taking the proper precautions, most attacks can be mitigated (even if they're st
 
 Ciphers implemented in this file:
     - Vigenere Cipher (Page 45)
+    - Autokey and Running Key Ciphers
+    - Beaufort, Variant Beaufort and Gronsfeld Ciphers
     - One Time Pad (Page 120)
     - DES/Lucifer (Page ???)
     - Diffe-Hellman-Merkle Key Exchange (Page 267)
+    - RSA (Page ???)
 */
 
 package ciphers
 
 import (
+	"bytes"
 	"crypto/rand"
+	"encoding/binary"
 	"errors"
-	// "fmt"
+	"fmt"
 	"math"
 	"math/big"
+	"slices"
 )
 
 /* The genius of the Vigenere cipher is that it employs multiple cipher alphabets, of which are in use is determined by a key. The
@@ -75,60 +81,625 @@ Decryption is also easy, given that you just do the steps backwards
 
 */
 
-func VigenereEncrypt(plaintext, keytext string) (string, error) {
-    if(len(plaintext) <= 0 || len(keytext) <= 0) {return "", errors.New("given empty string")}
-    plaintext, err := stripnonalpha(plaintext)
-    if len(plaintext) <= 0 || err != nil {return "", errors.New("could not strip non-alphanumeric characters from text")}
+/* Vigenere's square isn't the only way to lay out a tableau. Sir Francis Beaufort (of the wind scale) used one where the ciphertext
+letter is the key letter minus the plaintext letter, instead of the two added together. The nice thing about that is that it's
+reciprocal: taking the key minus the ciphertext gives the plaintext back, so enciphering and deciphering are the same operation. The
+variant Beaufort takes the key away from the plaintext instead, which is just the Vigenere square read backwards
 
-    var res string
+With 0 = 'A', every one of these is the same sort of rule, which is all a Tableau is:
 
-    for i := 0; i < len(plaintext); i++ {
-        res += string(((([]rune(plaintext)[i] - 'A') + ([]rune(keytext)[i%len(keytext)] - 'A') + 1) % ('Z' - 'A' + 1)) + 'A')
-    }
+    Vigenere:           C = P + K + 1   (the +1 is this package's square, see above)
+    Beaufort:           C = K - P
+    Variant Beaufort:   C = P - K
+
+Every cipher built on the Vigenere square (Vigenere, autokey, running key, the one time pad and Gronsfeld) takes WithTableau, so any
+of them can be done with any of the rules. Beaufort and variant Beaufort use the usual rules from the literature without the +1, so
+their test vectors line up with everybody else's
+*/
+
+// A rule for combining a letter of text with a letter of key, as their positions in the alphabet: the ciphertext letter is
+// text * P + key * K + shift. text has to be 1 or -1, or there'd be no way back
+type Tableau struct {
+    text, key, shift int
+}
+
+var (
+    VigenereTableau Tableau = Tableau{text: 1, key: 1, shift: 1}
+    BeaufortTableau Tableau = Tableau{text: -1, key: 1}
+    VariantBeaufortTableau Tableau = Tableau{text: 1, key: -1}
+)
+
+// The index of the ciphertext letter for plaintext letter p under key letter k. Doesn't wrap around, which is Alphabet.Rune's job
+func (t Tableau) encrypt(p, k int) int {
+    return t.text * p + t.key * k + t.shift
+}
+
+// The index of the plaintext letter for ciphertext letter c under key letter k. Doesn't wrap around either
+func (t Tableau) decrypt(c, k int) int {
+    return t.text * (c - t.key * k - t.shift)
+}
+
+// Encipher or decipher text letter by letter, with the key letter for each one coming from next. Punctuation kept by PreserveFormat
+// doesn't use up any key
+func tableauProcess(text string, decrypt bool, next func(i int) int, o options) (string, error) {
+    var i int
+    res := o.substitute(text, func(cur rune) rune {
+        ind, _ := o.alphabet.Index(cur)
+        k := next(i)
+        i++
+        if decrypt {return o.alphabet.Rune(o.tableau.decrypt(ind, k))}
+        return o.alphabet.Rune(o.tableau.encrypt(ind, k))
+    })
+    if len(res) <= 0 {return "", errors.New("could not strip non-alphanumeric characters from text")}
 
     return res, nil
 }
 
-func VigenereDecrypt(ciphertext, keytext string) (string, error) {
+// Turn a keytext into the index of each of its letters. Anything that isn't a letter of the alphabet is dropped, the same way it
+// would be from the text
+func vigenereKey(keytext string, alphabet *Alphabet) ([]int, error) {
+    keytext, err := alphabet.Strip(keytext)
+    if len(keytext) <= 0 || err != nil {return nil, errors.New("keytext has no letters in it")}
+
+    var key []int = make([]int, 0, len(keytext))
+    for _, cur := range keytext {
+        ind, _ := alphabet.Index(cur)
+        key = append(key, ind)
+    }
+
+    return key, nil
+}
+
+func VigenereEncrypt(plaintext, keytext string, opts ...Option) (string, error) {
+    if(len(plaintext) <= 0 || len(keytext) <= 0) {return "", errors.New("given empty string")}
+    o := getOptions(opts)
+    key, err := vigenereKey(keytext, o.alphabet)
+    if err != nil {return "", err}
+
+    return tableauProcess(plaintext, false, func(i int) int {return key[i%len(key)]}, o)
+}
+
+func VigenereDecrypt(ciphertext, keytext string, opts ...Option) (string, error) {
     if(len(ciphertext) <= 0 || len(keytext) <= 0) {return "", errors.New("given empty string")}
-    ciphertext, err := stripnonalpha(ciphertext)
-    if len(ciphertext) <= 0 || err != nil {return "", errors.New("could not strip non-alphanumeric characters from text")}
+    o := getOptions(opts)
+    key, err := vigenereKey(keytext, o.alphabet)
+    if err != nil {return "", err}
+
+    return tableauProcess(ciphertext, true, func(i int) int {return key[i%len(key)]}, o)
+}
 
-    var res string
+// Encipher a plaintext via the Beaufort cipher. It's reciprocal, so BeaufortDecrypt does exactly the same thing
+func BeaufortEncrypt(plaintext, keytext string, opts ...Option) (string, error) {
+    return VigenereEncrypt(plaintext, keytext, append(slices.Clone(opts), WithTableau(BeaufortTableau))...)
+}
 
-    for i, inter := 0, rune(0); i < len(ciphertext); i++ {
-        inter = ([]rune(ciphertext)[i] - 'A') - ([]rune(keytext)[i%len(keytext)] - 'A') - 1
-        if inter < 0 {inter += rune(ROMANWIDTH)}
-        res += string(inter + 'A')
+func BeaufortDecrypt(ciphertext, keytext string, opts ...Option) (string, error) {
+    return VigenereDecrypt(ciphertext, keytext, append(slices.Clone(opts), WithTableau(BeaufortTableau))...)
+}
+
+func VariantBeaufortEncrypt(plaintext, keytext string, opts ...Option) (string, error) {
+    return VigenereEncrypt(plaintext, keytext, append(slices.Clone(opts), WithTableau(VariantBeaufortTableau))...)
+}
+
+func VariantBeaufortDecrypt(ciphertext, keytext string, opts ...Option) (string, error) {
+    return VigenereDecrypt(ciphertext, keytext, append(slices.Clone(opts), WithTableau(VariantBeaufortTableau))...)
+}
+
+/* The Gronsfeld cipher is Vigenere with a number for a key instead of a word, so each digit is how far along to shift: with the key
+31415, the first letter moves along 3, the second 1 and so on. That only gives 10 alphabets instead of 26, which makes it quicker to
+use and quicker to break. The digits are turned into the key letters that shift that far under the tableau (under the Vigenere
+square 1 is A and 0 is the last letter of the alphabet, under either Beaufort 0 is A), so everything else about it is Vigenere,
+WithTableau included
+*/
+
+// The key letter that shifts by digit under tableau. The key letter counts key times, plus the shift, so taking the shift back off
+// (key is 1 or -1, so dividing by it is the same as multiplying) leaves the letter
+func (t Tableau) digitKey(digit int) int {
+    return digit - t.key * t.shift
+}
+
+// Turn a Gronsfeld key into the Vigenere keytext that does the same thing under the tableau the options give. Anything that isn't a
+// digit is ignored
+func gronsfeldKeytext(key string, o options) (string, error) {
+    if o.alphabet.Len() < 10 {return "", errors.New("alphabet is too short for a Gronsfeld key")}
+    var keytext []rune
+    for _, cur := range key {
+        if cur < '0' || cur > '9' {continue}
+        keytext = append(keytext, o.alphabet.Rune(o.tableau.digitKey(int(cur - '0'))))
     }
+    if len(keytext) <= 0 {return "", errors.New("key has no digits in it")}
+    return string(keytext), nil
+}
+
+func GronsfeldEncrypt(plaintext, key string, opts ...Option) (string, error) {
+    if len(plaintext) <= 0 || len(key) <= 0 {return "", errors.New("given empty string")}
+    keytext, err := gronsfeldKeytext(key, getOptions(opts))
+    if err != nil {return "", err}
+    return VigenereEncrypt(plaintext, keytext, opts...)
+}
+
+func GronsfeldDecrypt(ciphertext, key string, opts ...Option) (string, error) {
+    if len(ciphertext) <= 0 || len(key) <= 0 {return "", errors.New("given empty string")}
+    keytext, err := gronsfeldKeytext(key, getOptions(opts))
+    if err != nil {return "", err}
+    return VigenereDecrypt(ciphertext, keytext, opts...)
+}
+
+/* Kasiski's attack only works because the keytext repeats, so the obvious fix is a key that doesn't. Vigenere himself described
+the autokey cipher, where a short primer starts the key off and the plaintext itself carries it on from there:
+
+    Plaintext:  ATTACKATDAWN
+    Keytext:    QUEENLYATTAC
+    Ciphertext: ROYFQWZUXUXQ
+
+The receiver knows the primer, so they can decipher the first few letters, which gives them the next few letters of the key, and so
+on down the message. The running key cipher does the same thing with a book: both sides agree on a text, and the key is just the
+letters of the book from the start, for as long as the message goes on. Neither key ever repeats, so there's no period to find,
+but neither key is random either. The key is English, so the letters of it that line up with common plaintext letters are mostly
+common letters too, and that's enough to pull the two apart with enough ciphertext
+
+Both use the same tableau as VigenereEncrypt, where key A shifts by one
+*/
+
+// Encipher a plaintext via the autokey cipher: the primer, then the plaintext, is the keytext
+func AutokeyEncrypt(plaintext, primer string, opts ...Option) (string, error) {
+    if len(plaintext) <= 0 || len(primer) <= 0 {return "", errors.New("given empty string")}
+    o := getOptions(opts)
+    key, err := vigenereKey(primer, o.alphabet)
+    if err != nil {return "", err}
+
+    var i int
+    res := o.substitute(plaintext, func(cur rune) rune {
+        ind, _ := o.alphabet.Index(cur)
+        k := key[i]
+        key = append(key, ind)
+        i++
+        return o.alphabet.Rune(o.tableau.encrypt(ind, k))
+    })
+    if len(res) <= 0 {return "", errors.New("could not strip non-alphanumeric characters from text")}
 
     return res, nil
 }
 
+// Decipher a ciphertext via the autokey cipher. Every letter deciphered becomes part of the key for a letter further on
+func AutokeyDecrypt(ciphertext, primer string, opts ...Option) (string, error) {
+    if len(ciphertext) <= 0 || len(primer) <= 0 {return "", errors.New("given empty string")}
+    o := getOptions(opts)
+    key, err := vigenereKey(primer, o.alphabet)
+    if err != nil {return "", err}
+
+    var i int
+    res := o.substitute(ciphertext, func(cur rune) rune {
+        ind, _ := o.alphabet.Index(cur)
+        plain, _ := o.alphabet.Index(o.alphabet.Rune(o.tableau.decrypt(ind, key[i])))
+        key = append(key, plain)
+        i++
+        return o.alphabet.Rune(plain)
+    })
+    if len(res) <= 0 {return "", errors.New("could not strip non-alphanumeric characters from text")}
+
+    return res, nil
+}
+
+// Make sure a key (a book, or a one time pad) has at least as many letters as the text, since neither one is allowed to wrap
+// around. name is what to call the key in the error
+func checkKeyLength(text, keytext, name string, o options) error {
+    stripped, err := o.alphabet.Strip(text)
+    if err != nil {return err}
+    key, err := vigenereKey(keytext, o.alphabet)
+    if err != nil {return err}
+    if len(key) < len([]rune(stripped)) {
+        return fmt.Errorf("%v has %v letters, but the text needs %v", name, len(key), len([]rune(stripped)))
+    }
+    return nil
+}
+
+// Encipher a plaintext via the running key cipher, using the letters of booktext from the start as the keytext. The book has to
+// have at least as many letters as the plaintext
+func RunningKeyEncrypt(plaintext, booktext string, opts ...Option) (string, error) {
+    if len(plaintext) <= 0 || len(booktext) <= 0 {return "", errors.New("given empty string")}
+    if err := checkKeyLength(plaintext, booktext, "book", getOptions(opts)); err != nil {return "", err}
+    return VigenereEncrypt(plaintext, booktext, opts...)
+}
+
+// Decipher a ciphertext via the running key cipher, with the same book it was enciphered with
+func RunningKeyDecrypt(ciphertext, booktext string, opts ...Option) (string, error) {
+    if len(ciphertext) <= 0 || len(booktext) <= 0 {return "", errors.New("given empty string")}
+    if err := checkKeyLength(ciphertext, booktext, "book", getOptions(opts)); err != nil {return "", err}
+    return VigenereDecrypt(ciphertext, booktext, opts...)
+}
+
 /* The One Time Pad is the first truly unbreakable encryption scheme to be created, and relies on the Vigenere cipher. It is
 essentially a Vigenere Cipher with a random key that's as long as the plaintext. The keys would be distributed to sender and
 recipiant beforehand, then used to encrypt/decrypt a message. Once they were used, they were to be burned/destroyed as to 
 prevent the issue of reuse weaking the key. */
 
-func OTPEncrypt(plaintext string) (string, []rune, error) {
+func OTPEncrypt(plaintext string, opts ...Option) (string, []rune, error) {
     if len(plaintext) <= 0 {return "", nil, errors.New("given empty string")}
+    o := getOptions(opts)
     var ciphertext string
     var key []rune
     
-    randplacate := big.NewInt(int64(ROMANWIDTH))
+    randplacate := big.NewInt(int64(o.alphabet.Len()))
     for i := 0; i < len(plaintext); i++ {
         num, err := rand.Int(rand.Reader, randplacate)
         if err != nil {return "", nil, errors.New("could not generate random number")}
-        key = append(key, rune(num.Int64() + 'A'))
+        key = append(key, o.alphabet.Rune(int(num.Int64())))
     }
 
-    ciphertext, err := VigenereEncrypt(plaintext, string(key))
+    ciphertext, err := VigenereEncrypt(plaintext, string(key), opts...)
     return ciphertext, key, err
 }
 
-func OTPDecrypt(ciphertext string, key []rune) (string, error) {
+// Decipher a ciphertext with the pad it was enciphered with. A pad shorter than the ciphertext is an error: wrapping it around
+// would make it a plain old Vigenere key, which CrackVigenere can break
+func OTPDecrypt(ciphertext string, key []rune, opts ...Option) (string, error) {
     if len(ciphertext) <= 0 || len(key) <= 0 {return "", errors.New("given empty string or key")}
-    return VigenereDecrypt(ciphertext, string(key))
+    if err := checkKeyLength(ciphertext, string(key), "pad", getOptions(opts)); err != nil {return "", err}
+    return VigenereDecrypt(ciphertext, string(key), opts...)
+}
+
+/* DES, the Data Encryption Standard, was the US government's standard cipher from 1977 until it was replaced by AES in 2001. It
+grew out of Lucifer, a cipher Horst Feistel designed at IBM in the early 70s. The NSA had IBM cut the key down to 56 bits before it
+became the standard, small enough (so the story goes) that the NSA could break it and nobody else could. By 1998 the EFF could break
+it too, with a $250,000 machine called Deep Crack that found a key in a few days. (Page ???)
+
+Both are block ciphers: instead of working a letter at a time, they scramble a whole block of 64 bits at once. The scrambling is
+done by a Feistel network, which splits the block into a left and a right half and then, over and over:
+
+    new left  = right
+    new right = left XOR f(right, round key)
+
+f can be as ugly as it likes, and it is: DES expands the half to 48 bits, mixes in the round key, squeezes each 6 bit chunk back to
+4 bits through a substitution box, and shuffles the result. The beauty of the structure is that none of that has to be reversible,
+since decryption is just the same thing run with the round keys in reverse order. The round keys themselves come from the key
+schedule, which picks a different 48 bits of the key for every round
+
+DESTrace and LuciferTrace give back every round's halves and round key, which is the best way to actually see the avalanche
+happen: change one bit of the plaintext and watch it take over the whole block within a few rounds
+
+The byte functions (DESEncrypt and so on) run the block cipher over each 8 byte block of the message independently, which is called
+ECB mode, and pad the message out to a whole number of blocks (PKCS#7: n bytes of value n). ECB is fine for learning how the cipher
+works and terrible for anything else, since identical plaintext blocks come out as identical ciphertext blocks. Don't use DES for
+anything real either, Deep Crack is nearly 30 years old now
+*/
+
+// What one round of a Feistel network did. Left and Right are the halves going into the round, and Output is f(Right, Subkey),
+// which gets XORed into Left
+type FeistelRound struct {
+	Round int
+	Left, Right uint32
+	Subkey uint64
+	Output uint32
+}
+
+// The Feistel network shared by DES and Lucifer. f gets the round number (from 0) and the right half, and returns its output and
+// the round key it used. The halves are swapped after every round but the last, so running the same network with the round keys
+// reversed undoes it
+func feistel(left, right uint32, rounds int, f func(round int, half uint32) (uint32, uint64), trace *[]FeistelRound) (uint32, uint32) {
+    for i := 0; i < rounds; i++ {
+        out, subkey := f(i, right)
+        if trace != nil {*trace = append(*trace, FeistelRound{Round: i + 1, Left: left, Right: right, Subkey: subkey, Output: out})}
+        left, right = right, left ^ out
+    }
+
+    return right, left
+}
+
+// Pick bits out of in according to table. Bits are numbered from 1 at the most significant end, which is how the DES standard
+// writes its tables
+func permute(in uint64, inbits int, table []uint8) uint64 {
+    var out uint64
+    for _, bit := range table {
+        out = out << 1 | (in >> (inbits - int(bit))) & 1
+    }
+    return out
+}
+
+var (
+	desIP []uint8 = []uint8{
+		58, 50, 42, 34, 26, 18, 10, 2,
+		60, 52, 44, 36, 28, 20, 12, 4,
+		62, 54, 46, 38, 30, 22, 14, 6,
+		64, 56, 48, 40, 32, 24, 16, 8,
+		57, 49, 41, 33, 25, 17, 9, 1,
+		59, 51, 43, 35, 27, 19, 11, 3,
+		61, 53, 45, 37, 29, 21, 13, 5,
+		63, 55, 47, 39, 31, 23, 15, 7,
+	}
+	desFP []uint8 = []uint8{
+		40, 8, 48, 16, 56, 24, 64, 32,
+		39, 7, 47, 15, 55, 23, 63, 31,
+		38, 6, 46, 14, 54, 22, 62, 30,
+		37, 5, 45, 13, 53, 21, 61, 29,
+		36, 4, 44, 12, 52, 20, 60, 28,
+		35, 3, 43, 11, 51, 19, 59, 27,
+		34, 2, 42, 10, 50, 18, 58, 26,
+		33, 1, 41, 9, 49, 17, 57, 25,
+	}
+	desE []uint8 = []uint8{
+		32, 1, 2, 3, 4, 5,
+		4, 5, 6, 7, 8, 9,
+		8, 9, 10, 11, 12, 13,
+		12, 13, 14, 15, 16, 17,
+		16, 17, 18, 19, 20, 21,
+		20, 21, 22, 23, 24, 25,
+		24, 25, 26, 27, 28, 29,
+		28, 29, 30, 31, 32, 1,
+	}
+	desP []uint8 = []uint8{
+		16, 7, 20, 21, 29, 12, 28, 17,
+		1, 15, 23, 26, 5, 18, 31, 10,
+		2, 8, 24, 14, 32, 27, 3, 9,
+		19, 13, 30, 6, 22, 11, 4, 25,
+	}
+	desPC1 []uint8 = []uint8{
+		57, 49, 41, 33, 25, 17, 9,
+		1, 58, 50, 42, 34, 26, 18,
+		10, 2, 59, 51, 43, 35, 27,
+		19, 11, 3, 60, 52, 44, 36,
+		63, 55, 47, 39, 31, 23, 15,
+		7, 62, 54, 46, 38, 30, 22,
+		14, 6, 61, 53, 45, 37, 29,
+		21, 13, 5, 28, 20, 12, 4,
+	}
+	desPC2 []uint8 = []uint8{
+		14, 17, 11, 24, 1, 5,
+		3, 28, 15, 6, 21, 10,
+		23, 19, 12, 4, 26, 8,
+		16, 7, 27, 20, 13, 2,
+		41, 52, 31, 37, 47, 55,
+		30, 40, 51, 45, 33, 48,
+		44, 49, 39, 56, 34, 53,
+		46, 42, 50, 36, 29, 32,
+	}
+	desShifts [16]uint8 = [16]uint8{1, 1, 2, 2, 2, 2, 2, 2, 1, 2, 2, 2, 2, 2, 2, 1}
+	desS [8][64]uint8 = [8][64]uint8{
+		{
+			14, 4, 13, 1, 2, 15, 11, 8, 3, 10, 6, 12, 5, 9, 0, 7,
+			0, 15, 7, 4, 14, 2, 13, 1, 10, 6, 12, 11, 9, 5, 3, 8,
+			4, 1, 14, 8, 13, 6, 2, 11, 15, 12, 9, 7, 3, 10, 5, 0,
+			15, 12, 8, 2, 4, 9, 1, 7, 5, 11, 3, 14, 10, 0, 6, 13,
+		}, {
+			15, 1, 8, 14, 6, 11, 3, 4, 9, 7, 2, 13, 12, 0, 5, 10,
+			3, 13, 4, 7, 15, 2, 8, 14, 12, 0, 1, 10, 6, 9, 11, 5,
+			0, 14, 7, 11, 10, 4, 13, 1, 5, 8, 12, 6, 9, 3, 2, 15,
+			13, 8, 10, 1, 3, 15, 4, 2, 11, 6, 7, 12, 0, 5, 14, 9,
+		}, {
+			10, 0, 9, 14, 6, 3, 15, 5, 1, 13, 12, 7, 11, 4, 2, 8,
+			13, 7, 0, 9, 3, 4, 6, 10, 2, 8, 5, 14, 12, 11, 15, 1,
+			13, 6, 4, 9, 8, 15, 3, 0, 11, 1, 2, 12, 5, 10, 14, 7,
+			1, 10, 13, 0, 6, 9, 8, 7, 4, 15, 14, 3, 11, 5, 2, 12,
+		}, {
+			7, 13, 14, 3, 0, 6, 9, 10, 1, 2, 8, 5, 11, 12, 4, 15,
+			13, 8, 11, 5, 6, 15, 0, 3, 4, 7, 2, 12, 1, 10, 14, 9,
+			10, 6, 9, 0, 12, 11, 7, 13, 15, 1, 3, 14, 5, 2, 8, 4,
+			3, 15, 0, 6, 10, 1, 13, 8, 9, 4, 5, 11, 12, 7, 2, 14,
+		}, {
+			2, 12, 4, 1, 7, 10, 11, 6, 8, 5, 3, 15, 13, 0, 14, 9,
+			14, 11, 2, 12, 4, 7, 13, 1, 5, 0, 15, 10, 3, 9, 8, 6,
+			4, 2, 1, 11, 10, 13, 7, 8, 15, 9, 12, 5, 6, 3, 0, 14,
+			11, 8, 12, 7, 1, 14, 2, 13, 6, 15, 0, 9, 10, 4, 5, 3,
+		}, {
+			12, 1, 10, 15, 9, 2, 6, 8, 0, 13, 3, 4, 14, 7, 5, 11,
+			10, 15, 4, 2, 7, 12, 9, 5, 6, 1, 13, 14, 0, 11, 3, 8,
+			9, 14, 15, 5, 2, 8, 12, 3, 7, 0, 4, 10, 1, 13, 11, 6,
+			4, 3, 2, 12, 9, 5, 15, 10, 11, 14, 1, 7, 6, 0, 8, 13,
+		}, {
+			4, 11, 2, 14, 15, 0, 8, 13, 3, 12, 9, 7, 5, 10, 6, 1,
+			13, 0, 11, 7, 4, 9, 1, 10, 14, 3, 5, 12, 2, 15, 8, 6,
+			1, 4, 11, 13, 12, 3, 7, 14, 10, 15, 6, 8, 0, 5, 9, 2,
+			6, 11, 13, 8, 1, 4, 10, 7, 9, 5, 0, 15, 14, 2, 3, 12,
+		}, {
+			13, 2, 8, 4, 6, 15, 11, 1, 10, 9, 3, 14, 5, 0, 12, 7,
+			1, 15, 13, 8, 10, 3, 7, 4, 12, 5, 6, 11, 0, 14, 9, 2,
+			7, 11, 4, 1, 9, 12, 14, 2, 0, 6, 10, 13, 15, 3, 5, 8,
+			2, 1, 14, 7, 4, 10, 8, 13, 15, 12, 9, 0, 3, 5, 6, 11,
+		},
+	}
+)
+
+// The 16 round keys, 48 bits each. The 8 parity bits of the key (the lowest bit of every byte) are dropped by PC1 and never used
+func DESSubkeys(key uint64) [16]uint64 {
+    var subkeys [16]uint64
+    var cd uint64 = permute(key, 64, desPC1)
+    var c, d uint32 = uint32(cd >> 28), uint32(cd & 0xFFFFFFF)
+
+    for i, shift := range desShifts {
+        c = (c << shift | c >> (28 - shift)) & 0xFFFFFFF
+        d = (d << shift | d >> (28 - shift)) & 0xFFFFFFF
+        subkeys[i] = permute(uint64(c) << 28 | uint64(d), 56, desPC2)
+    }
+
+    return subkeys
+}
+
+// The DES round function: expand, mix in the round key, substitute, permute
+func desF(half uint32, subkey uint64) uint32 {
+    var x uint64 = permute(uint64(half), 32, desE) ^ subkey
+    var out uint32
+
+    for i := 0; i < 8; i++ {
+        chunk := (x >> (42 - 6*i)) & 0x3F
+        // The outer two bits pick the row, the inner four pick the column
+        row := (chunk >> 4) & 2 | chunk & 1
+        col := (chunk >> 1) & 0xF
+        out = out << 4 | uint32(desS[i][row*16 + col])
+    }
+
+    return uint32(permute(uint64(out), 32, desP))
+}
+
+func desBlock(block, key uint64, decrypt bool, trace *[]FeistelRound) uint64 {
+    subkeys := DESSubkeys(key)
+    block = permute(block, 64, desIP)
+
+    left, right := feistel(uint32(block >> 32), uint32(block), 16, func(round int, half uint32) (uint32, uint64) {
+        if decrypt {round = 15 - round}
+        return desF(half, subkeys[round]), subkeys[round]
+    }, trace)
+
+    return permute(uint64(left) << 32 | uint64(right), 64, desFP)
+}
+
+func DESEncryptBlock(block, key uint64) uint64 {
+    return desBlock(block, key, false, nil)
+}
+
+func DESDecryptBlock(block, key uint64) uint64 {
+    return desBlock(block, key, true, nil)
+}
+
+// Encrypt (or decrypt) a single block, and give back what happened in each of the 16 rounds. The halves in the trace are after
+// the initial permutation, since that's what the rounds actually see
+func DESTrace(block, key uint64, decrypt bool) (uint64, []FeistelRound) {
+    var trace []FeistelRound = make([]FeistelRound, 0, 16)
+    res := desBlock(block, key, decrypt, &trace)
+    return res, trace
+}
+
+// Pad text out to a whole number of blocks, PKCS#7 style. A message that's already a whole number of blocks gets a whole block
+// of padding, so there's never any doubt about whether the last byte is padding
+func padBlocks(text []byte, size int) []byte {
+    n := size - len(text) % size
+    res := make([]byte, len(text), len(text) + n)
+    copy(res, text)
+    for i := 0; i < n; i++ {
+        res = append(res, byte(n))
+    }
+    return res
+}
+
+func unpadBlocks(text []byte, size int) ([]byte, error) {
+    if len(text) <= 0 || len(text) % size != 0 {return nil, errors.New("text is not a whole number of blocks")}
+    n := int(text[len(text) - 1])
+    if n <= 0 || n > size {return nil, errors.New("text has invalid padding")}
+    for _, cur := range text[len(text) - n:] {
+        if int(cur) != n {return nil, errors.New("text has invalid padding")}
+    }
+    return text[:len(text) - n], nil
+}
+
+// Run block over every 8 byte block of text, in ECB mode
+func ecb(text []byte, block func(uint64) uint64) []byte {
+    var res []byte = make([]byte, len(text))
+    for i := 0; i < len(text); i += 8 {
+        binary.BigEndian.PutUint64(res[i:], block(binary.BigEndian.Uint64(text[i:])))
+    }
+    return res
+}
+
+// Encrypt plaintext with an 8 byte key, in ECB mode with PKCS#7 padding
+func DESEncrypt(plaintext, key []byte) ([]byte, error) {
+    if len(plaintext) <= 0 {return nil, errors.New("given empty plaintext")}
+    if len(key) != 8 {return nil, errors.New("DES key has to be 8 bytes long")}
+    k := binary.BigEndian.Uint64(key)
+
+    return ecb(padBlocks(plaintext, 8), func(block uint64) uint64 {return DESEncryptBlock(block, k)}), nil
+}
+
+func DESDecrypt(ciphertext, key []byte) ([]byte, error) {
+    if len(ciphertext) <= 0 {return nil, errors.New("given empty ciphertext")}
+    if len(ciphertext) % 8 != 0 {return nil, errors.New("ciphertext is not a whole number of blocks")}
+    if len(key) != 8 {return nil, errors.New("DES key has to be 8 bytes long")}
+    k := binary.BigEndian.Uint64(key)
+
+    return unpadBlocks(ecb(ciphertext, func(block uint64) uint64 {return DESDecryptBlock(block, k)}), 8)
+}
+
+/* Lucifer never had a single definitive version. IBM built several, and the one that was published (Sorkin's 1984 description in
+Cryptologia) works on 128 bit blocks with a 128 bit key. This is a scaled down version of that design, on the same 64 bit blocks as
+DES so that the two can be compared side by side. It keeps the things that made Lucifer Lucifer:
+
+    - Two 4 bit substitution boxes, S0 and S1, taken from Sorkin's description
+    - "Interchange control bits" from the key, which decide whether each byte's two halves are swapped before going through the
+      S-boxes. The key doesn't just get mixed into the data, it changes which S-box each nibble meets
+    - A 128 bit key with a very simple schedule: every round reads 5 bytes out of a 16 byte key register, then moves 7 bytes along
+
+Round i reads key bytes 7i through 7i+4 (wrapping around). The first byte is the interchange control byte, whose low 4 bits go with
+the 4 bytes of the half, and the other 4 are XORed into the S-box output. The result is then spread out by a bit permutation that
+sends bit j to bit 11j+5 (mod 32), so every byte of the output gets bits from every byte of the input. Since 16 * 7 is a multiple of
+16, the schedule ends up back where it started, and decryption just reads the same round keys backwards
+
+The result is a perfectly reasonable toy cipher, and about as secure as you'd expect from something with two 4 bit S-boxes
+*/
+
+var (
+	luciferS0 [16]uint8 = [16]uint8{12, 15, 7, 10, 14, 13, 11, 0, 2, 6, 3, 1, 9, 4, 5, 8}
+	luciferS1 [16]uint8 = [16]uint8{7, 2, 14, 9, 3, 11, 0, 4, 12, 13, 1, 10, 6, 15, 8, 5}
+)
+
+// The 16 round keys. Each one is the interchange control byte followed by the 4 key bytes, 40 bits in all
+func LuciferSubkeys(key [16]byte) [16]uint64 {
+    var subkeys [16]uint64
+    for i := range subkeys {
+        for j := 0; j < 5; j++ {
+            subkeys[i] = subkeys[i] << 8 | uint64(key[(7*i + j) % 16])
+        }
+    }
+    return subkeys
+}
+
+func luciferF(half uint32, subkey uint64) uint32 {
+    var icb byte = byte(subkey >> 32)
+    var mixed uint32
+
+    for j := 0; j < 4; j++ {
+        cur := byte(half >> (24 - 8*j))
+        if icb >> (3 - j) & 1 == 1 {cur = cur << 4 | cur >> 4}
+        cur = luciferS1[cur >> 4] << 4 | luciferS0[cur & 0xF]
+        mixed = mixed << 8 | uint32(cur ^ byte(subkey >> (24 - 8*j)))
+    }
+
+    var out uint32
+    for j := 0; j < 32; j++ {
+        out |= (mixed >> j & 1) << ((11*j + 5) % 32)
+    }
+    return out
+}
+
+func luciferBlock(block uint64, key [16]byte, decrypt bool, trace *[]FeistelRound) uint64 {
+    subkeys := LuciferSubkeys(key)
+
+    left, right := feistel(uint32(block >> 32), uint32(block), 16, func(round int, half uint32) (uint32, uint64) {
+        if decrypt {round = 15 - round}
+        return luciferF(half, subkeys[round]), subkeys[round]
+    }, trace)
+
+    return uint64(left) << 32 | uint64(right)
+}
+
+func LuciferEncryptBlock(block uint64, key [16]byte) uint64 {
+    return luciferBlock(block, key, false, nil)
+}
+
+func LuciferDecryptBlock(block uint64, key [16]byte) uint64 {
+    return luciferBlock(block, key, true, nil)
+}
+
+// Same as DESTrace. Lucifer has no initial permutation, so the halves are exactly the halves of the block
+func LuciferTrace(block uint64, key [16]byte, decrypt bool) (uint64, []FeistelRound) {
+    var trace []FeistelRound = make([]FeistelRound, 0, 16)
+    res := luciferBlock(block, key, decrypt, &trace)
+    return res, trace
+}
+
+// Encrypt plaintext with a 16 byte key, in ECB mode with PKCS#7 padding
+func LuciferEncrypt(plaintext, key []byte) ([]byte, error) {
+    if len(plaintext) <= 0 {return nil, errors.New("given empty plaintext")}
+    if len(key) != 16 {return nil, errors.New("Lucifer key has to be 16 bytes long")}
+    k := [16]byte(key)
+
+    return ecb(padBlocks(plaintext, 8), func(block uint64) uint64 {return LuciferEncryptBlock(block, k)}), nil
+}
+
+func LuciferDecrypt(ciphertext, key []byte) ([]byte, error) {
+    if len(ciphertext) <= 0 {return nil, errors.New("given empty ciphertext")}
+    if len(ciphertext) % 8 != 0 {return nil, errors.New("ciphertext is not a whole number of blocks")}
+    if len(key) != 16 {return nil, errors.New("Lucifer key has to be 16 bytes long")}
+    k := [16]byte(key)
+
+    return unpadBlocks(ecb(ciphertext, func(block uint64) uint64 {return LuciferDecryptBlock(block, k)}), 8)
 }
 
 
@@ -152,7 +723,7 @@ understand the eliptic curve variant, so I will not implement it (as of now, I m
 
 func DHMkeStep1(y, p *big.Int) (*big.Int, *big.Int, error) {
     if y == nil || p == nil {return nil, nil, errors.New("got nil y or p")}
-    // if p.BitLen() < 2048 {return nil, nil, errors.New("p is too small of a number. Should be at least 2048 bits long. Got: " + fmt.Sprint(p.BitLen()))}
+    if err := (DHMParams{P: p, G: y}).Validate(); err != nil {return nil, nil, err}
     
     secret, err := rand.Int(rand.Reader, big.NewInt(math.MaxInt64))
     if err != nil {return nil, nil, errors.New("could not generate secret number")}
@@ -165,12 +736,118 @@ func DHMkeStep1(y, p *big.Int) (*big.Int, *big.Int, error) {
 
 func DHMkeStep2(shared, secret, p *big.Int) (*big.Int, error) {
     if shared == nil || secret == nil || p == nil {return nil, errors.New("given nil big.Int pointer")}
+    if err := validateDHMPrime(p); err != nil {return nil, err}
+    // A shared number of 0, 1 or p-1 would force the key to one of those too, which is exactly what an attacker sitting in the
+    // middle would like to send
+    if err := validateDHMElement(shared, p); err != nil {return nil, errors.New("shared number has to be between 2 and p-2")}
+
     return big.NewInt(0).Exp(shared, secret, p), nil    
     // Again, still vulnerable to side-channel attacks
 }
 
-// TODO: Make some function that guarantees that p is prime, and that y is a primitive root 
-// modulo of p. 
+/* Nothing about the exchange works unless p and y are chosen properly. If p isn't prime, the "one-way" step can be undone one
+factor at a time. If y only generates a handful of numbers mod p (y = 1, or y = p-1, which only ever gives 1 and p-1), the shared
+key can be guessed outright. And if p is small, the whole thing can just be brute forced
+
+The standard fix is a safe prime: a prime p where q = (p-1)/2 is also prime. The numbers mod p then only have four possible orders
+(1, 2, q and 2q), so any y from 2 to p-2 is guaranteed to generate either all of them (a primitive root, order 2q) or the half of
+them that are squares (order q). Either is fine, and the second is actually a little better, since it doesn't leak whether the
+secret number was even or odd. That's why the standard groups everyone uses, like the 2048 bit one from RFC 3526, use y = 2 even
+though 2 isn't a primitive root for them
+
+DHMParams checks all of that, and DHMkeStep1 and DHMkeStep2 refuse to work with anything that doesn't pass. Finding a safe prime takes
+a while (minutes, for 2048 bits), so DHMGroup14 is there for when you'd rather not wait
+*/
+
+// The smallest p the key exchange will accept
+const DHMMINBITS int = 2048
+
+// Public parameters for the key exchange: the prime modulus P and the generator G (the "y" in DHMkeStep1)
+type DHMParams struct {
+	P, G *big.Int
+}
+
+// The 2048 bit MODP group from RFC 3526, with generator 2
+var DHMGroup14 DHMParams = DHMParams{
+	P: mustHex("FFFFFFFFFFFFFFFFC90FDAA22168C234C4C6628B80DC1CD129024E088A67CC74020BBEA63B139B22514A08798E3404DD" +
+		"EF9519B3CD3A431B302B0A6DF25F14374FE1356D6D51C245E485B576625E7EC6F44C42E9A637ED6B0BFF5CB6F406B7ED" +
+		"EE386BFB5A899FA5AE9F24117C4B1FE649286651ECE45B3DC2007CB8A163BF0598DA48361C55D39A69163FA8FD24CF5F" +
+		"83655D23DCA3AD961C62F356208552BB9ED529077096966D670C354E4ABC9804F1746C08CA18217C32905E462E36CE3B" +
+		"E39E772C180E86039B2783A2EC07A28FB5C55DF06F4C52C9DE2BCBF6955817183995497CEA956AE515D2261898FA0510" +
+		"15728E5A8AACAA68FFFFFFFFFFFFFFFF"),
+	G: big.NewInt(2),
+}
+
+func mustHex(text string) *big.Int {
+    res, ok := big.NewInt(0).SetString(text, 16)
+    if !ok {panic("invalid hex number " + text)}
+    return res
+}
+
+// Check that p is big enough and a safe prime
+func validateDHMPrime(p *big.Int) error {
+    if p == nil {return errors.New("got nil p")}
+    if p.BitLen() < DHMMINBITS {return errors.New("p is too small of a number. Should be at least " + fmt.Sprint(DHMMINBITS) + " bits long. Got: " + fmt.Sprint(p.BitLen()))}
+    if !p.ProbablyPrime(20) {return errors.New("p is not prime")}
+
+    q := big.NewInt(0).Rsh(p, 1)
+    if !q.ProbablyPrime(20) {return errors.New("p is not a safe prime, (p-1)/2 is not prime")}
+
+    return nil
+}
+
+// Check that the value is between 2 and p-2. Anything else generates at most 2 numbers mod p
+func validateDHMElement(y, p *big.Int) error {
+    if y == nil {return errors.New("got nil y")}
+    pm1 := big.NewInt(0).Sub(p, big.NewInt(1))
+    if y.Cmp(big.NewInt(1)) <= 0 || y.Cmp(pm1) >= 0 {return errors.New("y has to be between 2 and p-2, or it only generates 1 and p-1")}
+
+    return nil
+}
+
+// Make sure the parameters are safe to do a key exchange with: P has to be a safe prime of at least DHMMINBITS bits, and G has to
+// generate a subgroup of order (P-1)/2 or P-1
+func (params DHMParams) Validate() error {
+    if err := validateDHMPrime(params.P); err != nil {return err}
+    if err := validateDHMElement(params.G, params.P); err != nil {return err}
+
+    // With a safe prime this can't actually fail once G is between 2 and p-2, but it's the property that matters, so check it
+    if params.GeneratorOrder().BitLen() < params.P.BitLen() - 1 {return errors.New("y does not generate a large enough subgroup")}
+
+    return nil
+}
+
+// The order of G mod P, assuming P is a safe prime: 1, 2, (P-1)/2 or P-1
+func (params DHMParams) GeneratorOrder() *big.Int {
+    one := big.NewInt(1)
+    pm1 := big.NewInt(0).Sub(params.P, one)
+    q := big.NewInt(0).Rsh(params.P, 1)
+
+    switch {
+    case params.G.Cmp(one) == 0: return one
+    case big.NewInt(0).Exp(params.G, big.NewInt(2), params.P).Cmp(one) == 0: return big.NewInt(2)
+    case big.NewInt(0).Exp(params.G, q, params.P).Cmp(one) == 0: return q
+    default: return pm1
+    }
+}
+
+// Generate a safe prime with the given number of bits, and the smallest generator that works with it (which is always 2, see
+// above). Anything under DHMMINBITS is only good for experimenting with, and won't be accepted by DHMkeStep1 or DHMkeStep2
+func GenerateDHMParams(bits int) (DHMParams, error) {
+    if bits < 16 {return DHMParams{}, errors.New("bits is too small, should be at least 16. Got: " + fmt.Sprint(bits))}
+    one := big.NewInt(1)
+
+    for {
+        q, err := rand.Prime(rand.Reader, bits - 1)
+        if err != nil {return DHMParams{}, errors.New("could not generate prime")}
+
+        p := big.NewInt(0).Lsh(q, 1)
+        p.Add(p, one)
+        if p.BitLen() != bits || !p.ProbablyPrime(20) {continue}
+
+        return DHMParams{P: p, G: big.NewInt(2)}, nil
+    }
+}
 
 
 /* RSA, short for Rivest Shamir Adleman, was the first (publicly available) asymmetric cryptosystem to be invented, and is still 
@@ -185,3 +862,159 @@ people can verify that your communication is actually from you via your public k
 
 And now I have to implement it because it's in the book */
 
+
+/* The whole trick is that multiplying two primes together is easy, and splitting the product back into the two primes is not.
+Alice picks two big primes p and q and publishes their product N, along with some number e. To send her a message M (written as a
+number smaller than N), Bob works out C = M^e mod N. Undoing that requires knowing (p-1)(q-1), which requires knowing p and q,
+which only Alice does. She works out the d that makes e * d = 1 mod (p-1)(q-1), and gets the message back with M = C^d mod N
+
+The example in the book uses p = 17 and q = 11, so N = 187, with e = 7 and d = 23. Bob sends Alice the letter X, which is 88 in
+ASCII: 88^7 mod 187 = 11, and 11^23 mod 187 = 88 again
+
+Doing exactly that is called "textbook" RSA (RSAEncrypt/RSADecrypt), and it's only good for textbooks. The same message always
+encrypts to the same ciphertext, small messages with small e can be undone with an ordinary root, and the ciphertexts can be
+multiplied together to make new valid ones. Real RSA pads the message with random bytes first, so the padded functions
+(RSAEncryptPadded/RSADecryptPadded) use the padding from PKCS #1 v1.5:
+
+    0x00 0x02 [at least 8 random nonzero bytes] 0x00 [message]
+
+which is what most of the internet used for a couple decades. They're kept apart from the textbook functions on purpose; a padded
+ciphertext is not something you should ever be decrypting with RSADecrypt
+
+Decryption is the slow part, since d is as big as N. Knowing p and q makes it about 4 times faster, thanks to the Chinese Remainder
+Theorem: work out C^d mod p and C^d mod q separately (with much smaller numbers), then stitch the two answers back together.
+RSADecryptCRT does it that way, and so does RSADecryptPadded
+*/
+
+type RSAPublicKey struct {
+	N, E *big.Int
+}
+
+// The private key keeps p and q around, along with the numbers RSADecryptCRT needs: d mod (p-1), d mod (q-1) and the inverse
+// of q mod p
+type RSAPrivateKey struct {
+	RSAPublicKey
+	D, P, Q *big.Int
+	Dp, Dq, Qinv *big.Int
+}
+
+// Build a key out of two primes and a public exponent. This is how you'd recreate the example from the book; RSAGenerateKey is
+// for everything else
+func NewRSAKey(p, q, e *big.Int) (*RSAPrivateKey, error) {
+    if p == nil || q == nil || e == nil {return nil, errors.New("given nil big.Int pointer")}
+    if !p.ProbablyPrime(20) || !q.ProbablyPrime(20) {return nil, errors.New("p and q have to be prime")}
+    if p.Cmp(q) == 0 {return nil, errors.New("p and q have to be different primes")}
+    if e.Cmp(big.NewInt(1)) <= 0 {return nil, errors.New("e has to be bigger than 1")}
+
+    one := big.NewInt(1)
+    pm1 := big.NewInt(0).Sub(p, one)
+    qm1 := big.NewInt(0).Sub(q, one)
+    phi := big.NewInt(0).Mul(pm1, qm1)
+
+    d := big.NewInt(0).ModInverse(e, phi)
+    if d == nil {return nil, errors.New("e has to be coprime with (p-1)(q-1)")}
+    qinv := big.NewInt(0).ModInverse(q, p)
+
+    return &RSAPrivateKey{
+        RSAPublicKey: RSAPublicKey{N: big.NewInt(0).Mul(p, q), E: big.NewInt(0).Set(e)},
+        D: d,
+        P: big.NewInt(0).Set(p),
+        Q: big.NewInt(0).Set(q),
+        Dp: big.NewInt(0).Mod(d, pm1),
+        Dq: big.NewInt(0).Mod(d, qm1),
+        Qinv: qinv,
+    }, nil
+}
+
+// Generate a key with an N of the given number of bits, and e = 65537 like everyone else uses
+func RSAGenerateKey(bits int) (*RSAPrivateKey, error) {
+    if bits < 512 {return nil, errors.New("N is too small of a number. Should be at least 512 bits long (2048 for anything real). Got: " + fmt.Sprint(bits))}
+    e := big.NewInt(65537)
+
+    // rand.Prime sets the top two bits of each prime, so their product always has exactly the bits asked for. Once in a blue moon
+    // the primes come out equal or with e dividing p-1, so just try again
+    for {
+        p, err := rand.Prime(rand.Reader, bits - bits/2)
+        if err != nil {return nil, errors.New("could not generate prime")}
+        q, err := rand.Prime(rand.Reader, bits/2)
+        if err != nil {return nil, errors.New("could not generate prime")}
+
+        key, err := NewRSAKey(p, q, e)
+        if err == nil {return key, nil}
+    }
+}
+
+// Textbook RSA: C = M^e mod N. The message has to be a number from 0 to N-1
+func RSAEncrypt(message *big.Int, key *RSAPublicKey) (*big.Int, error) {
+    if message == nil || key == nil || key.N == nil || key.E == nil {return nil, errors.New("given nil message or key")}
+    if message.Sign() < 0 || message.Cmp(key.N) >= 0 {return nil, errors.New("message has to be between 0 and N-1")}
+
+    return big.NewInt(0).Exp(message, key.E, key.N), nil
+    // Just like DHM, not time constant
+}
+
+// Textbook RSA: M = C^d mod N
+func RSADecrypt(ciphertext *big.Int, key *RSAPrivateKey) (*big.Int, error) {
+    if ciphertext == nil || key == nil || key.N == nil || key.D == nil {return nil, errors.New("given nil ciphertext or key")}
+    if ciphertext.Sign() < 0 || ciphertext.Cmp(key.N) >= 0 {return nil, errors.New("ciphertext has to be between 0 and N-1")}
+
+    return big.NewInt(0).Exp(ciphertext, key.D, key.N), nil
+}
+
+// Same as RSADecrypt, but works mod p and mod q separately and combines the two with the CRT (Garner's formula):
+//
+//     m1 = C^Dp mod p,  m2 = C^Dq mod q,  h = Qinv * (m1 - m2) mod p,  M = m2 + h*q
+func RSADecryptCRT(ciphertext *big.Int, key *RSAPrivateKey) (*big.Int, error) {
+    if ciphertext == nil || key == nil || key.N == nil {return nil, errors.New("given nil ciphertext or key")}
+    if key.P == nil || key.Q == nil || key.Dp == nil || key.Dq == nil || key.Qinv == nil {return nil, errors.New("key is missing the CRT values")}
+    if ciphertext.Sign() < 0 || ciphertext.Cmp(key.N) >= 0 {return nil, errors.New("ciphertext has to be between 0 and N-1")}
+
+    m1 := big.NewInt(0).Exp(ciphertext, key.Dp, key.P)
+    m2 := big.NewInt(0).Exp(ciphertext, key.Dq, key.Q)
+
+    h := big.NewInt(0).Sub(m1, m2)
+    h.Mul(h, key.Qinv)
+    h.Mod(h, key.P)
+
+    return h.Mul(h, key.Q).Add(h, m2), nil
+}
+
+// Pad the message with PKCS #1 v1.5 and encrypt it. The message can be at most 11 bytes shorter than N
+func RSAEncryptPadded(message []byte, key *RSAPublicKey) ([]byte, error) {
+    if key == nil || key.N == nil || key.E == nil {return nil, errors.New("given nil key")}
+    k := (key.N.BitLen() + 7) / 8
+    if len(message) > k - 11 {return nil, errors.New("message is too long for the key. Can be at most " + fmt.Sprint(k - 11) + " bytes")}
+
+    var padded []byte = make([]byte, k)
+    padded[1] = 2
+    filler := padded[2:k - len(message) - 1]
+    if _, err := rand.Read(filler); err != nil {return nil, errors.New("could not generate padding")}
+    for i := range filler {
+        for filler[i] == 0 {
+            if _, err := rand.Read(filler[i:i + 1]); err != nil {return nil, errors.New("could not generate padding")}
+        }
+    }
+    copy(padded[k - len(message):], message)
+
+    c, err := RSAEncrypt(big.NewInt(0).SetBytes(padded), key)
+    if err != nil {return nil, err}
+    return c.FillBytes(make([]byte, k)), nil
+}
+
+// Decrypt with the CRT and strip the PKCS #1 v1.5 padding. Every way the padding can be wrong gives the same error, so that the
+// error can't be used to learn anything about the plaintext (look up Bleichenbacher's attack for why that matters)
+func RSADecryptPadded(ciphertext []byte, key *RSAPrivateKey) ([]byte, error) {
+    if key == nil || key.N == nil {return nil, errors.New("given nil key")}
+    k := (key.N.BitLen() + 7) / 8
+    if len(ciphertext) != k || k < 11 {return nil, errors.New("decryption error")}
+
+    m, err := RSADecryptCRT(big.NewInt(0).SetBytes(ciphertext), key)
+    if err != nil {return nil, errors.New("decryption error")}
+    padded := m.FillBytes(make([]byte, k))
+
+    if padded[0] != 0 || padded[1] != 2 {return nil, errors.New("decryption error")}
+    end := bytes.IndexByte(padded[2:], 0)
+    if end < 8 {return nil, errors.New("decryption error")}
+
+    return padded[2 + end + 1:], nil
+}
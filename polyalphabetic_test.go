@@ -1,6 +1,14 @@
 package ciphers
 
 import (
+	"bytes"
+	"crypto/des"
+	crand "crypto/rand"
+	"crypto/rsa"
+	"encoding/binary"
+	"math/big"
+	"math/bits"
+	"math/rand/v2"
 	"testing"
 )
 
@@ -20,6 +28,129 @@ func TestVigenere(t *testing.T) {
 	}
 }
 
+func TestBeaufort(t *testing.T) {
+	const PLAINTEXT string	= "DEFENDTHEEASTWALLOFTHECASTLE"
+	const CIPHERTEXT string	= "CKMPVCPVWPIWUJOGIUAPVWRIWUUK"
+
+	res1, err := BeaufortEncrypt(PLAINTEXT, "FORTIFICATION")
+	if res1 != CIPHERTEXT || err != nil {
+		t.Errorf("Got incorrect ciphertext from Beaufort encryption: %v (%v)", res1, err)
+	}
+	// It's reciprocal, so enciphering the ciphertext again gives the plaintext back
+	res2, err := BeaufortEncrypt(CIPHERTEXT, "FORTIFICATION")
+	res3, _ := BeaufortDecrypt(CIPHERTEXT, "FORTIFICATION")
+	if res2 != PLAINTEXT || res3 != PLAINTEXT || err != nil {
+		t.Errorf("Got incorrect plaintext from Beaufort decryption: %v, %v (%v)", res2, res3, err)
+	}
+
+	// The variant is the ordinary Vigenere square run backwards
+	res4, err := VariantBeaufortEncrypt("Attack at dawn!", "LEMON", PreserveFormat())
+	if res4 != "Pphmpz wh pnlj!" || err != nil {
+		t.Errorf("Got incorrect ciphertext from variant Beaufort encryption: %v (%v)", res4, err)
+	}
+	res5, err := VariantBeaufortDecrypt(res4, "LEMON", PreserveFormat())
+	if res5 != "Attack at dawn!" || err != nil {
+		t.Errorf("Got incorrect plaintext from variant Beaufort decryption: %v (%v)", res5, err)
+	}
+
+	// Any cipher on the Vigenere square can be switched over to another tableau
+	res6, _ := AutokeyEncrypt(PLAINTEXT, "FORTIFICATION", WithTableau(BeaufortTableau))
+	if res6[:13] != CIPHERTEXT[:13] {
+		t.Errorf("Autokey with the Beaufort tableau doesn't match Beaufort inside the primer: %v", res6)
+	}
+	res7, err := AutokeyDecrypt(res6, "FORTIFICATION", WithTableau(BeaufortTableau))
+	if res7 != PLAINTEXT || err != nil {
+		t.Errorf("Got incorrect plaintext from autokey decryption with the Beaufort tableau: %v (%v)", res7, err)
+	}
+}
+
+func TestGronsfeld(t *testing.T) {
+	res1, err := GronsfeldEncrypt("ATTACKATDAWN", "31415")
+	if res1 != "DUXBHNBXEFZO" || err != nil {
+		t.Errorf("Got incorrect ciphertext from Gronsfeld encryption: %v (%v)", res1, err)
+	}
+	res2, err := GronsfeldDecrypt(res1, "3-1-4-1-5")
+	if res2 != "ATTACKATDAWN" || err != nil {
+		t.Errorf("Got incorrect plaintext from Gronsfeld decryption: %v (%v)", res2, err)
+	}
+
+	// 0 doesn't shift at all
+	if res3, _ := GronsfeldEncrypt("ATTACK", "0"); res3 != "ATTACK" {
+		t.Errorf("Key of 0 changed the plaintext: %v", res3)
+	}
+	if _, err := GronsfeldEncrypt("ATTACK", "LEMON"); err == nil {
+		t.Errorf("Encrypted with a key with no digits")
+	}
+
+	// Each digit is still the shift under the other tableaus: the key minus the plaintext for Beaufort, and the plaintext minus the
+	// key for variant Beaufort, which undoes the Vigenere one
+	res4, err := GronsfeldEncrypt("ATTACKATDAWN", "31415", WithTableau(BeaufortTableau))
+	if res4 != "DILBDTBLYFHO" || err != nil {
+		t.Errorf("Got incorrect ciphertext from Gronsfeld encryption with the Beaufort tableau: %v (%v)", res4, err)
+	}
+	res5, err := GronsfeldDecrypt(res4, "31415", WithTableau(BeaufortTableau))
+	if res5 != "ATTACKATDAWN" || err != nil {
+		t.Errorf("Got incorrect plaintext from Gronsfeld decryption with the Beaufort tableau: %v (%v)", res5, err)
+	}
+	res6, err := GronsfeldEncrypt("ATTACKATDAWN", "31415", WithTableau(VariantBeaufortTableau))
+	if res6 != "XSPZXHZPCVTM" || err != nil {
+		t.Errorf("Got incorrect ciphertext from Gronsfeld encryption with the variant Beaufort tableau: %v (%v)", res6, err)
+	}
+	if res7, _ := GronsfeldEncrypt(res1, "31415", WithTableau(VariantBeaufortTableau)); res7 != "ATTACKATDAWN" {
+		t.Errorf("Variant Beaufort didn't undo Vigenere: %v", res7)
+	}
+}
+
+func TestAutokey(t *testing.T) {
+	// The usual example is QNXEPVYTWTWP, but VigenereEncrypt's tableau shifts everything one further along
+	res1, err := AutokeyEncrypt("Attack at dawn", "QUEENLY")
+	if res1 != "ROYFQWZUXUXQ" || err != nil {
+		t.Errorf("Got incorrect ciphertext from autokey encryption: %v (%v)", res1, err)
+	}
+	res2, err := AutokeyDecrypt(res1, "queenly")
+	if res2 != "ATTACKATDAWN" || err != nil {
+		t.Errorf("Got incorrect plaintext from autokey decryption: %v (%v)", res2, err)
+	}
+
+	// Until the primer runs out, autokey is just Vigenere
+	res3, _ := AutokeyEncrypt("Attack!", "QUEENLYXYZ", PreserveFormat())
+	res4, _ := VigenereEncrypt("Attack!", "QUEENLY", PreserveFormat())
+	if res3 != res4 || res3 != "Royfqw!" {
+		t.Errorf("Autokey doesn't match Vigenere inside the primer: %v vs %v", res3, res4)
+	}
+	res5, err := AutokeyDecrypt(res3, "QUEENLYXYZ", PreserveFormat())
+	if res5 != "Attack!" || err != nil {
+		t.Errorf("Got incorrect plaintext from formatted autokey decryption: %v (%v)", res5, err)
+	}
+
+	if _, err := AutokeyEncrypt("ATTACK", "123"); err == nil {
+		t.Errorf("Encrypted with a primer with no letters")
+	}
+}
+
+func TestRunningKey(t *testing.T) {
+	const PLAINTEXT string 	= "THYSECRETISTHYPRISONERIFTHOULETITGOTHOUARTAPRISONERTOIT"
+	const BOOKTEXT string	= "When in the Course of human events, it becomes necessary for one people to dissolve the political bands"
+
+	res1, err := RunningKeyEncrypt(PLAINTEXT, BOOKTEXT)
+	res2, _ := VigenereEncrypt(PLAINTEXT, BOOKTEXT)
+	if res1 != res2 || err != nil {
+		t.Errorf("Got incorrect ciphertext from running key encryption: %v (%v)", res1, err)
+	}
+	res3, err := RunningKeyDecrypt(res1, BOOKTEXT)
+	if res3 != PLAINTEXT || err != nil {
+		t.Errorf("Got incorrect plaintext from running key decryption: %v (%v)", res3, err)
+	}
+
+	// The key can't wrap around, or it's just Vigenere with a long key
+	if _, err := RunningKeyEncrypt(PLAINTEXT, "When in the Course of human events"); err == nil {
+		t.Errorf("Encrypted with a book shorter than the plaintext")
+	}
+	if _, err := RunningKeyDecrypt(res1, "When in the Course of human events"); err == nil {
+		t.Errorf("Decrypted with a book shorter than the ciphertext")
+	}
+}
+
 func TestOTP(t *testing.T) {
 	const PLAINTEXT string = "WELLANDTRULYUNBREAKABLE"
 	
@@ -32,4 +163,307 @@ func TestOTP(t *testing.T) {
 	if res2 != PLAINTEXT || err != nil {
 		t.Errorf("Got incorrect output from OPTDecrypt: %v %v (%v)", res2, key, err)
 	}
-}
\ No newline at end of file
+
+	// A pad shorter than the text would have to wrap around, which makes it a Vigenere key
+	if _, err := OTPDecrypt(res1, key[:10]); err == nil {
+		t.Errorf("Decrypted with a pad shorter than the ciphertext")
+	}
+	short := &OTPCipher{Pad: key[:10]}
+	if _, err := short.Encrypt(PLAINTEXT); err == nil {
+		t.Errorf("Encrypted with a pad shorter than the plaintext")
+	}
+
+	// Spaces and punctuation don't use up any of the pad
+	formatted := &OTPCipher{Pad: key[:len(PLAINTEXT)], Options: []Option{PreserveFormat()}}
+	res3, err := formatted.Encrypt("Well and truly, unbreakable!")
+	if err != nil {
+		t.Errorf("Could not encrypt with a pad exactly as long as the plaintext: %v", err)
+	}
+	res4, err := OTPDecrypt(res3, formatted.Pad, PreserveFormat())
+	if res4 != "Well and truly, unbreakable!" || err != nil {
+		t.Errorf("Got incorrect output from OTPDecrypt with a formatted ciphertext: %v (%v)", res4, err)
+	}
+}
+
+func TestDES(t *testing.T) {
+	// The worked example everyone learns DES from, and a key that happens to encrypt a block to all zeros
+	tests := []struct {
+		key, plaintext, ciphertext uint64
+	}{
+		{0x133457799BBCDFF1, 0x0123456789ABCDEF, 0x85E813540F0AB405},
+		{0x0E329232EA6D0D73, 0x8787878787878787, 0x0000000000000000},
+		{0x0123456789ABCDEF, 0x4E6F772069732074, 0x3FA40E8A984D4815},
+	}
+
+	for _, test := range tests {
+		res1 := DESEncryptBlock(test.plaintext, test.key)
+		if res1 != test.ciphertext {
+			t.Errorf("Got incorrect block from DES encryption with key %016X: %016X", test.key, res1)
+		}
+		res2 := DESDecryptBlock(res1, test.key)
+		if res2 != test.plaintext {
+			t.Errorf("Got incorrect block from DES decryption with key %016X: %016X", test.key, res2)
+		}
+	}
+
+	// Check a pile of random blocks against the standard library too
+	var key, block, want [8]byte
+	for i := 0; i < 100; i++ {
+		binary.BigEndian.PutUint64(key[:], rand.Uint64())
+		binary.BigEndian.PutUint64(block[:], rand.Uint64())
+		c, _ := des.NewCipher(key[:])
+		c.Encrypt(want[:], block[:])
+
+		res := DESEncryptBlock(binary.BigEndian.Uint64(block[:]), binary.BigEndian.Uint64(key[:]))
+		if res != binary.BigEndian.Uint64(want[:]) {
+			t.Errorf("DES disagrees with crypto/des for key %X block %X: %016X vs %X", key, block, res, want)
+		}
+	}
+
+	const PLAINTEXT string = "Now is the time for all good men to come to the aid of their country"
+	res3, err := DESEncrypt([]byte(PLAINTEXT), []byte("SECRETKY"))
+	if len(res3) % 8 != 0 || len(res3) <= len(PLAINTEXT) || err != nil {
+		t.Errorf("Got incorrect ciphertext from DES encryption: %X (%v)", res3, err)
+	}
+	res4, err := DESDecrypt(res3, []byte("SECRETKY"))
+	if string(res4) != PLAINTEXT || err != nil {
+		t.Errorf("Got incorrect plaintext from DES decryption: %v (%v)", res4, err)
+	}
+	if _, err := DESEncrypt([]byte(PLAINTEXT), []byte("SHORT")); err == nil {
+		t.Errorf("DES accepted a key that isn't 8 bytes")
+	}
+	if _, err := DESDecrypt(res3, []byte("WRONGKEY")); err == nil {
+		t.Errorf("DES decrypted with the wrong key and found valid padding")
+	}
+}
+
+func TestFeistelTrace(t *testing.T) {
+	res1, trace := DESTrace(0x0123456789ABCDEF, 0x133457799BBCDFF1, false)
+	if res1 != 0x85E813540F0AB405 || len(trace) != 16 {
+		t.Fatalf("Got incorrect output from DES trace: %016X %v", res1, trace)
+	}
+
+	// The first round of the worked example: L0 and R0 after the initial permutation, K1, and f(R0, K1)
+	first := trace[0]
+	if first.Left != 0xCC00CCFF || first.Right != 0xF0AAF0AA || first.Subkey != 0x1B02EFFC7072 || first.Output != 0x234AA9BB {
+		t.Errorf("Got incorrect first round from DES trace: %+v", first)
+	}
+	// Every round's right half becomes the next round's left half
+	for i := 1; i < len(trace); i++ {
+		if trace[i].Left != trace[i - 1].Right || trace[i].Right != trace[i - 1].Left ^ trace[i - 1].Output {
+			t.Errorf("Round %v doesn't follow from round %v: %+v %+v", i + 1, i, trace[i - 1], trace[i])
+		}
+	}
+
+	// Decryption uses the round keys backwards
+	res2, dtrace := DESTrace(res1, 0x133457799BBCDFF1, true)
+	if res2 != 0x0123456789ABCDEF || dtrace[0].Subkey != trace[15].Subkey {
+		t.Errorf("Got incorrect output from DES decryption trace: %016X %+v", res2, dtrace[0])
+	}
+}
+
+func TestLucifer(t *testing.T) {
+	var key [16]byte = [16]byte([]byte("HORST FEISTEL 71"))
+
+	for i := 0; i < 100; i++ {
+		block := rand.Uint64()
+		res1 := LuciferEncryptBlock(block, key)
+		if res1 == block {
+			t.Errorf("Lucifer left block %016X unchanged", block)
+		}
+		res2 := LuciferDecryptBlock(res1, key)
+		if res2 != block {
+			t.Errorf("Got incorrect block from Lucifer decryption: %016X vs %016X", res2, block)
+		}
+	}
+
+	// Flipping one bit of the plaintext should change about half of the ciphertext
+	var total int
+	for i := 0; i < 64; i++ {
+		total += bits.OnesCount64(LuciferEncryptBlock(0, key) ^ LuciferEncryptBlock(1 << i, key))
+	}
+	if avg := float64(total) / 64; avg < 24 || avg > 40 {
+		t.Errorf("Lucifer has poor avalanche, %v bits change on average", avg)
+	}
+
+	res3, trace := LuciferTrace(0x0123456789ABCDEF, key, false)
+	if res3 != LuciferEncryptBlock(0x0123456789ABCDEF, key) || len(trace) != 16 || trace[0].Left != 0x01234567 {
+		t.Errorf("Got incorrect output from Lucifer trace: %016X %+v", res3, trace)
+	}
+
+	const PLAINTEXT string = "Lucifer was the light-bringer, and the predecessor to DES"
+	res4, err := LuciferEncrypt([]byte(PLAINTEXT), key[:])
+	if len(res4) % 8 != 0 || err != nil {
+		t.Errorf("Got incorrect ciphertext from Lucifer encryption: %X (%v)", res4, err)
+	}
+	res5, err := LuciferDecrypt(res4, key[:])
+	if !bytes.Equal(res5, []byte(PLAINTEXT)) || err != nil {
+		t.Errorf("Got incorrect plaintext from Lucifer decryption: %v (%v)", res5, err)
+	}
+}
+
+func TestRSA(t *testing.T) {
+	// Alice's key from the book, and Bob's message: the letter X
+	book, err := NewRSAKey(big.NewInt(17), big.NewInt(11), big.NewInt(7))
+	if book == nil || err != nil {
+		t.Fatalf("Could not make the key from the book: %v", err)
+	}
+	if book.N.Int64() != 187 || book.D.Int64() != 23 {
+		t.Errorf("Got incorrect key from the book's primes: N = %v, d = %v", book.N, book.D)
+	}
+	res1, err := RSAEncrypt(big.NewInt('X'), &book.RSAPublicKey)
+	if res1 == nil || res1.Int64() != 11 || err != nil {
+		t.Errorf("Got incorrect ciphertext from textbook RSA: %v (%v)", res1, err)
+	}
+	res2, err := RSADecrypt(res1, book)
+	if res2 == nil || res2.Int64() != 'X' || err != nil {
+		t.Errorf("Got incorrect plaintext from textbook RSA: %v (%v)", res2, err)
+	}
+	res3, err := RSADecryptCRT(res1, book)
+	if res3 == nil || res3.Int64() != 'X' || err != nil {
+		t.Errorf("Got incorrect plaintext from CRT RSA: %v (%v)", res3, err)
+	}
+
+	if _, err := NewRSAKey(big.NewInt(17), big.NewInt(11), big.NewInt(5)); err == nil {
+		t.Errorf("Made a key with an e that shares a factor with (p-1)(q-1)")
+	}
+	if _, err := NewRSAKey(big.NewInt(15), big.NewInt(11), big.NewInt(7)); err == nil {
+		t.Errorf("Made a key out of a number that isn't prime")
+	}
+	if _, err := RSAEncrypt(big.NewInt(187), &book.RSAPublicKey); err == nil {
+		t.Errorf("Encrypted a message that doesn't fit under N")
+	}
+
+	key, err := RSAGenerateKey(1024)
+	if key == nil || key.N.BitLen() != 1024 || err != nil {
+		t.Fatalf("Could not generate key: %v (%v)", key, err)
+	}
+	if _, err := RSAGenerateKey(128); err == nil {
+		t.Errorf("Generated a key that's far too small")
+	}
+
+	for i := 0; i < 10; i++ {
+		m, _ := crand.Int(crand.Reader, key.N)
+		c, err := RSAEncrypt(m, &key.RSAPublicKey)
+		if err != nil {
+			t.Fatalf("Got error from textbook RSA encryption: %v", err)
+		}
+		res4, err := RSADecrypt(c, key)
+		res5, err2 := RSADecryptCRT(c, key)
+		if res4.Cmp(m) != 0 || res5.Cmp(m) != 0 || err != nil || err2 != nil {
+			t.Errorf("RSA decryption didn't give back the message: %v %v %v (%v, %v)", m, res4, res5, err, err2)
+		}
+	}
+
+	// Padded messages have to be readable by crypto/rsa, and the other way around
+	const PLAINTEXT string = "The magic words are squeamish ossifrage"
+	std := &rsa.PrivateKey{
+		PublicKey: rsa.PublicKey{N: key.N, E: int(key.E.Int64())},
+		D: key.D,
+		Primes: []*big.Int{key.P, key.Q},
+	}
+	std.Precompute()
+
+	res6, err := RSAEncryptPadded([]byte(PLAINTEXT), &key.RSAPublicKey)
+	if len(res6) != 128 || err != nil {
+		t.Fatalf("Got incorrect ciphertext from padded RSA: %X (%v)", res6, err)
+	}
+	res7, err := rsa.DecryptPKCS1v15(nil, std, res6)
+	if string(res7) != PLAINTEXT || err != nil {
+		t.Errorf("crypto/rsa could not decrypt padded RSA: %v (%v)", res7, err)
+	}
+	res8, _ := rsa.EncryptPKCS1v15(crand.Reader, &std.PublicKey, []byte(PLAINTEXT))
+	res9, err := RSADecryptPadded(res8, key)
+	if string(res9) != PLAINTEXT || err != nil {
+		t.Errorf("Could not decrypt padded RSA from crypto/rsa: %v (%v)", res9, err)
+	}
+
+	// The same message padded twice shouldn't look the same
+	res10, _ := RSAEncryptPadded([]byte(PLAINTEXT), &key.RSAPublicKey)
+	if bytes.Equal(res6, res10) {
+		t.Errorf("Padded RSA gave the same ciphertext twice")
+	}
+	if _, err := RSAEncryptPadded(make([]byte, 118), &key.RSAPublicKey); err == nil {
+		t.Errorf("Padded a message that's too long for the key")
+	}
+
+	// A textbook ciphertext has no padding, so decrypting it as padded has to fail
+	raw, _ := RSAEncrypt(big.NewInt(0).SetBytes([]byte(PLAINTEXT)), &key.RSAPublicKey)
+	if _, err := RSADecryptPadded(raw.FillBytes(make([]byte, 128)), key); err == nil {
+		t.Errorf("Decrypted an unpadded ciphertext as padded")
+	}
+}
+
+func TestDHM(t *testing.T) {
+	if err := DHMGroup14.Validate(); err != nil {
+		t.Fatalf("RFC 3526 group 14 did not validate: %v", err)
+	}
+	// 2 is a square mod the group 14 prime, so it generates the half of the group of order (p-1)/2
+	if res := DHMGroup14.GeneratorOrder(); res.Cmp(big.NewInt(0).Rsh(DHMGroup14.P, 1)) != 0 {
+		t.Errorf("Got incorrect generator order for group 14: %v", res)
+	}
+
+	asecret, ashared, err := DHMkeStep1(DHMGroup14.G, DHMGroup14.P)
+	if err != nil {
+		t.Fatalf("Got error from Alice's first step: %v", err)
+	}
+	bsecret, bshared, err := DHMkeStep1(DHMGroup14.G, DHMGroup14.P)
+	if err != nil {
+		t.Fatalf("Got error from Bob's first step: %v", err)
+	}
+	akey, err := DHMkeStep2(bshared, asecret, DHMGroup14.P)
+	if err != nil {
+		t.Fatalf("Got error from Alice's second step: %v", err)
+	}
+	bkey, err := DHMkeStep2(ashared, bsecret, DHMGroup14.P)
+	if akey.Cmp(bkey) != 0 || err != nil {
+		t.Errorf("Alice and Bob ended up with different keys: %v %v (%v)", akey, bkey, err)
+	}
+
+	// The book's example uses y = 7 and p = 11, which is fine for a book and nothing else
+	if _, _, err := DHMkeStep1(big.NewInt(7), big.NewInt(11)); err == nil {
+		t.Errorf("Accepted a p that's too small")
+	}
+	composite := big.NewInt(0).Lsh(big.NewInt(1), 2048)
+	composite.Add(composite, big.NewInt(1))
+	if _, _, err := DHMkeStep1(big.NewInt(2), composite); err == nil {
+		t.Errorf("Accepted a p that isn't prime")
+	}
+	pm1 := big.NewInt(0).Sub(DHMGroup14.P, big.NewInt(1))
+	for _, y := range []*big.Int{big.NewInt(0), big.NewInt(1), pm1, DHMGroup14.P} {
+		if _, _, err := DHMkeStep1(y, DHMGroup14.P); err == nil {
+			t.Errorf("Accepted a generator of %v", y)
+		}
+	}
+	if _, err := DHMkeStep2(big.NewInt(1), asecret, DHMGroup14.P); err == nil {
+		t.Errorf("Accepted a shared number of 1")
+	}
+	if _, err := DHMkeStep2(pm1, asecret, DHMGroup14.P); err == nil {
+		t.Errorf("Accepted a shared number of p-1")
+	}
+}
+
+func TestGenerateDHMParams(t *testing.T) {
+	params, err := GenerateDHMParams(128)
+	if params.P == nil || params.P.BitLen() != 128 || err != nil {
+		t.Fatalf("Could not generate parameters: %v (%v)", params, err)
+	}
+
+	q := big.NewInt(0).Rsh(params.P, 1)
+	if !params.P.ProbablyPrime(20) || !q.ProbablyPrime(20) {
+		t.Errorf("Generated p that isn't a safe prime: %v", params.P)
+	}
+	if order := params.GeneratorOrder(); order.Cmp(q) < 0 {
+		t.Errorf("Generated g with too small of an order: %v", order)
+	}
+
+	// Everything about these is fine except the size
+	if err := params.Validate(); err == nil {
+		t.Errorf("Validated parameters that are too small")
+	}
+	// A random prime is almost never a safe one
+	prime, _ := crand.Prime(crand.Reader, DHMMINBITS)
+	if err := (DHMParams{P: prime, G: big.NewInt(2)}).Validate(); err == nil {
+		t.Errorf("Validated a prime that isn't safe: %v", prime)
+	}
+}
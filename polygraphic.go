@@ -0,0 +1,334 @@
+/** POLYGRAPHIC CIPHERS
+- "A substitution cipher in which groups of letters are replaced by other groups of letters"
+
+Every substitution cipher so far swaps one letter at a time, which is exactly what frequency analysis feeds on: however the
+letters get disguised, E is still E every time it comes up under the same alphabet. Polygraphic ciphers swap whole blocks of
+letters at once, so the same letter comes out differently depending on the letters around it. A block of 2 letters has 676 possible
+values instead of 26, and there are far fewer places to start counting from
+
+Ciphers implemented in this file:
+    - Hill Cipher
+    - Playfair Cipher
+*/
+
+package ciphers
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+)
+
+/* Lester Hill's cipher (1929) was the first one built entirely out of algebra. Number the letters from 0 (A = 0 ... Z = 25), cut the
+plaintext into blocks of n letters, and multiply each block by an n×n key matrix, wrapping around at 26. For n = 3, with the key
+GYBNQKURP written row by row:
+
+    | 6 24  1 |   | A = 0  |   |  67 |            | 15 = P |
+    |13 16 10 | × | C = 2  | = | 222 | (mod 26) = | 14 = O |
+    |20 17 15 |   | T = 19 |   | 319 |            |  7 = H |
+
+So ACT becomes POH. Every letter of the ciphertext block depends on every letter of the plaintext block, which is what makes it
+polygraphic. A message that doesn't fill its last block gets padded out with nulls (X unless WithPadding says otherwise)
+
+Decrypting multiplies by the inverse matrix instead, and that's where the catch is. A matrix only has an inverse mod 26 if its
+determinant does, and (like the affine cipher's multiplier) that means the determinant can't share a factor with 26. The inverse is
+the determinant's inverse times the adjugate, the matrix of cofactors flipped over its diagonal. For GYBNQKURP:
+
+    | 8  5 10 |
+    |21  8 21 |
+    |21 12  8 |
+
+The algebra is also its undoing. Because encryption is linear, n blocks of known plaintext and their ciphertext are enough to
+solve for the key, the same way you'd solve n simultaneous equations (see CrackHill)
+*/
+
+// Turn a keyword of n² letters into the n×n key matrix it spells out, row by row. Anything that isn't a letter is dropped first
+func HillMatrix(keyword string) ([][]int, error) {
+    stripped, err := stripnonalpha(keyword)
+    if err != nil {return nil, err}
+    size := int(math.Round(math.Sqrt(float64(len(stripped)))))
+    if size <= 0 || size * size != len(stripped) {return nil, fmt.Errorf("keyword has %v letters, which isn't a square number", len(stripped))}
+
+    var matrix [][]int = make([][]int, size)
+    for i, cur := range stripped {
+        ind, _ := RomanAlphabet.Index(cur)
+        matrix[i / size] = append(matrix[i / size], ind)
+    }
+    return matrix, nil
+}
+
+// Spell a key matrix out as letters, row by row. The opposite of HillMatrix
+func hillKeyword(matrix [][]int) string {
+    var keyword strings.Builder
+    for _, row := range matrix {
+        for _, cur := range row {
+            keyword.WriteRune(RomanAlphabet.Rune(modulo(cur, ROMANWIDTH)))
+        }
+    }
+    return keyword.String()
+}
+
+// matrix without the given row and column
+func minor(matrix [][]int, row, col int) [][]int {
+    var res [][]int
+    for i, cur := range matrix {
+        if i == row {continue}
+        var line []int
+        for j, val := range cur {
+            if j != col {line = append(line, val)}
+        }
+        res = append(res, line)
+    }
+    return res
+}
+
+// Determinant of a square matrix mod width, by cofactor expansion along the first row. That takes n! steps, which is nothing for
+// the sizes anyone would use by hand
+func determinant(matrix [][]int, width int) int {
+    if len(matrix) == 1 {return modulo(matrix[0][0], width)}
+
+    var det int
+    for col, cur := range matrix[0] {
+        cofactor := cur * determinant(minor(matrix, 0, col), width)
+        if col % 2 == 1 {cofactor = -cofactor}
+        det = modulo(det + cofactor, width)
+    }
+    return det
+}
+
+// Make sure matrix is square and has an inverse mod ROMANWIDTH, and hand the inverse back
+func hillInverse(matrix [][]int) ([][]int, error) {
+    if len(matrix) <= 0 {return nil, errors.New("given empty key matrix")}
+    for _, row := range matrix {
+        if len(row) != len(matrix) {return nil, fmt.Errorf("key matrix is not square (%v rows, a row of %v)", len(matrix), len(row))}
+    }
+
+    det := determinant(matrix, ROMANWIDTH)
+    if gcd(det, ROMANWIDTH) != 1 {return nil, fmt.Errorf("key matrix has determinant %v, which shares a factor with %v, so it can't be undone", det, ROMANWIDTH)}
+    detinverse, err := modInverse(det, ROMANWIDTH)
+    if err != nil {return nil, err}
+
+    // The adjugate is the transpose of the cofactors, so row i column j of the inverse comes from the minor at row j column i
+    var inverse [][]int = make([][]int, len(matrix))
+    for i := range inverse {
+        inverse[i] = make([]int, len(matrix))
+        for j := range inverse[i] {
+            if len(matrix) == 1 {
+                inverse[i][j] = detinverse
+                continue
+            }
+            cofactor := determinant(minor(matrix, j, i), ROMANWIDTH)
+            if (i + j) % 2 == 1 {cofactor = -cofactor}
+            inverse[i][j] = modulo(detinverse * cofactor, ROMANWIDTH)
+        }
+    }
+    return inverse, nil
+}
+
+// Multiply every block of text by matrix
+func hillProcess(text []int, matrix [][]int) string {
+    size := len(matrix)
+    var res []rune = make([]rune, 0, len(text))
+    for start := 0; start + size <= len(text); start += size {
+        for _, row := range matrix {
+            var sum int
+            for j, cur := range row {
+                sum += cur * text[start + j]
+            }
+            res = append(res, RomanAlphabet.Rune(modulo(sum, ROMANWIDTH)))
+        }
+    }
+    return string(res)
+}
+
+// Strip text down to A-Z, and fill out its last block with nulls
+func hillText(text string, size int, o options) ([]int, error) {
+    stripped, err := stripnonalpha(text)
+    if err != nil {return nil, err}
+    if len(stripped) <= 0 {return nil, errors.New("text has no letters in it")}
+
+    var null rune = 'X'
+    if o.padding != 0 {
+        var valid bool
+        null, valid = RomanAlphabet.Normalize(o.padding)
+        if !valid {return nil, fmt.Errorf("null %q is not a letter", o.padding)}
+    }
+    for len(stripped) % size != 0 {
+        stripped += string(null)
+    }
+
+    var res []int = make([]int, len(stripped))
+    for i, cur := range stripped {
+        res[i], _ = RomanAlphabet.Index(cur)
+    }
+    return res, nil
+}
+
+// Encipher a plaintext via the Hill cipher, with an n×n key matrix (see HillMatrix). Only works over A-Z, so WithAlphabet is
+// ignored. Takes WithPadding
+func HillEncrypt(plaintext string, matrix [][]int, opts ...Option) (string, error) {
+    if len(plaintext) <= 0 {return "", errors.New("given empty string")}
+    if _, err := hillInverse(matrix); err != nil {return "", err}
+    text, err := hillText(plaintext, len(matrix), getOptions(opts))
+    if err != nil {return "", err}
+
+    return hillProcess(text, matrix), nil
+}
+
+// Decipher a ciphertext via the Hill cipher. Any nulls added to the end of the plaintext are still there afterwards
+func HillDecrypt(ciphertext string, matrix [][]int, opts ...Option) (string, error) {
+    if len(ciphertext) <= 0 {return "", errors.New("given empty string")}
+    inverse, err := hillInverse(matrix)
+    if err != nil {return "", err}
+    text, err := hillText(ciphertext, len(matrix), getOptions(opts))
+    if err != nil {return "", err}
+
+    return hillProcess(text, inverse), nil
+}
+
+
+/* Charles Wheatstone came up with the Playfair cipher in 1854, but it's named after his friend Lord Playfair, who spent years talking
+the British government into using it. It stayed in service a long time: the British army used it in the Boer War and the First
+World War, where it was valued more for being quick to do by hand than for being unbreakable
+
+The key is a 5×5 square of letters. Write out the keyphrase without any repeated letters (the same way the keyphrase cipher does),
+then the rest of the alphabet in order. That's only 25 spaces, so one letter has to go: usually J, which is written as I. With the
+keyphrase PLAYFAIR EXAMPLE:
+
+    P L A Y F
+    I R E X M
+    B C D G H
+    K N O Q S
+    T U V W Z
+
+The plaintext is split into pairs of letters. A pair can't be the same letter twice, so an X goes between doubled letters, and
+another one on the end if there's a letter left over:
+
+    HIDE THE GOLD IN THE TREE STUMP
+    HI DE TH EG OL DI NT HE TR EX ES TU MP
+
+Then each pair is swapped for another pair from the square:
+
+    - Both letters in the same row: take the letter to the right of each one, wrapping around to the start of the row
+    - Both in the same column: take the letter below each one, wrapping around to the top
+    - Otherwise they mark the corners of a rectangle: take the letter in the same row at the other corner
+
+    BM OD ZB XD NA BE KU DM UI XM MO UV IF
+
+Deciphering goes left and up instead of right and down. The Xs that were added are still there afterwards, but they're easy enough
+to spot and cross out by hand
+
+WithPadding picks the filler letter, and WithAlphabet which letters are merged: any alphabet with a square number of letters will
+do, with folds for the letters that are written as another one (see NewFoldedAlphabet). Since A-Z has 26 letters, it's swapped for
+PlayfairAlphabet
+*/
+
+// The alphabet to use for the Playfair square, and the length of its sides
+func playfairAlphabet(o options) (*Alphabet, int, error) {
+    alphabet := o.alphabet
+    if alphabet == RomanAlphabet {alphabet = PlayfairAlphabet}
+    size := int(math.Round(math.Sqrt(float64(alphabet.Len()))))
+    if size * size != alphabet.Len() {return nil, 0, fmt.Errorf("alphabet has %v letters, which won't fill a square", alphabet.Len())}
+    return alphabet, size, nil
+}
+
+// The letters of the Playfair square for keyphrase, row by row
+func playfairSquare(keyphrase string, alphabet *Alphabet) ([]rune, error) {
+    stripped, err := alphabet.Strip(keyphrase)
+    if err != nil {return nil, err}
+    if len(stripped) <= 0 {return nil, errors.New("keyphrase has no letters in it")}
+    return uniqueLetters([]rune(stripped + alphabet.String())), nil
+}
+
+// The filler letter, and the one to use when the filler letter itself is doubled
+func playfairFillers(alphabet *Alphabet, o options) (rune, rune, error) {
+    var filler rune = 'X'
+    if o.padding != 0 {filler = o.padding}
+    filler, valid := alphabet.Normalize(filler)
+    if !valid {return 0, 0, fmt.Errorf("filler %q is not in the alphabet", filler)}
+
+    ind, _ := alphabet.Index(filler)
+    return filler, alphabet.Rune(ind + 1), nil
+}
+
+// Split text into pairs, with filler between doubled letters and on the end
+func playfairDigraphs(text []rune, filler, alternate rune) []rune {
+    var res []rune = make([]rune, 0, len(text) + len(text) / 2)
+    for i := 0; i < len(text); {
+        first := text[i]
+        if i + 1 < len(text) && text[i + 1] != first {
+            res = append(res, first, text[i + 1])
+            i += 2
+            continue
+        }
+
+        if first == filler {
+            res = append(res, first, alternate)
+        } else {
+            res = append(res, first, filler)
+        }
+        i++
+    }
+    return res
+}
+
+// Swap every pair of text for another pair from the square, moving step places along rows and columns: 1 to encipher, -1 to
+// decipher
+func playfairProcess(text []rune, square []rune, size int, step int) []rune {
+    var positions map[rune]int = make(map[rune]int, len(square))
+    for i, cur := range square {
+        positions[cur] = i
+    }
+    at := func(row, col int) rune {return square[modulo(row, size) * size + modulo(col, size)]}
+
+    var res []rune = make([]rune, len(text))
+    for i := 0; i + 1 < len(text); i += 2 {
+        row1, col1 := positions[text[i]] / size, positions[text[i]] % size
+        row2, col2 := positions[text[i + 1]] / size, positions[text[i + 1]] % size
+        switch {
+        case row1 == row2:
+            res[i], res[i + 1] = at(row1, col1 + step), at(row2, col2 + step)
+        case col1 == col2:
+            res[i], res[i + 1] = at(row1 + step, col1), at(row2 + step, col2)
+        default:
+            res[i], res[i + 1] = at(row1, col2), at(row2, col1)
+        }
+    }
+    return res
+}
+
+// Encipher a plaintext via the Playfair cipher. Takes WithAlphabet and WithPadding (X by default)
+func PlayfairEncrypt(plaintext, keyphrase string, opts ...Option) (string, error) {
+    if len(plaintext) <= 0 || len(keyphrase) <= 0 {return "", errors.New("given empty string")}
+    o := getOptions(opts)
+    alphabet, size, err := playfairAlphabet(o)
+    if err != nil {return "", err}
+    square, err := playfairSquare(keyphrase, alphabet)
+    if err != nil {return "", err}
+    filler, alternate, err := playfairFillers(alphabet, o)
+    if err != nil {return "", err}
+
+    stripped, err := alphabet.Strip(plaintext)
+    if err != nil {return "", err}
+    if len(stripped) <= 0 {return "", errors.New("plaintext has no letters in it")}
+
+    return string(playfairProcess(playfairDigraphs([]rune(stripped), filler, alternate), square, size, 1)), nil
+}
+
+// Decipher a ciphertext via the Playfair cipher. Fillers added during encryption are left in. Takes WithAlphabet
+func PlayfairDecrypt(ciphertext, keyphrase string, opts ...Option) (string, error) {
+    if len(ciphertext) <= 0 || len(keyphrase) <= 0 {return "", errors.New("given empty string")}
+    o := getOptions(opts)
+    alphabet, size, err := playfairAlphabet(o)
+    if err != nil {return "", err}
+    square, err := playfairSquare(keyphrase, alphabet)
+    if err != nil {return "", err}
+
+    stripped, err := alphabet.Strip(ciphertext)
+    if err != nil {return "", err}
+    text := []rune(stripped)
+    if len(text) <= 0 || len(text) % 2 != 0 {return "", fmt.Errorf("ciphertext has %v letters, which won't split into pairs", len(text))}
+
+    return string(playfairProcess(text, square, size, -1)), nil
+}
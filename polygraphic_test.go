@@ -0,0 +1,108 @@
+package ciphers
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestHill(t *testing.T) {
+	key, err := HillMatrix("GYBNQKURP")
+	if !slices.EqualFunc(key, [][]int{{6, 24, 1}, {13, 16, 10}, {20, 17, 15}}, slices.Equal) || err != nil {
+		t.Fatalf("Got incorrect key matrix: %v (%v)", key, err)
+	}
+
+	res1, err := HillEncrypt("Act, cat!", key)
+	if res1 != "POHFIN" || err != nil {
+		t.Errorf("Got incorrect string from Hill encryption: %v (%v)", res1, err)
+	}
+	res2, err := HillDecrypt(res1, key)
+	if res2 != "ACTCAT" || err != nil {
+		t.Errorf("Got incorrect string from Hill decryption: %v (%v)", res2, err)
+	}
+
+	inverse, err := hillInverse(key)
+	if !slices.EqualFunc(inverse, [][]int{{8, 5, 10}, {21, 8, 21}, {21, 12, 8}}, slices.Equal) || err != nil {
+		t.Errorf("Got incorrect inverse key matrix: %v (%v)", inverse, err)
+	}
+
+	// The last block gets filled out with nulls, which are still there after decrypting
+	res3, _ := HillEncrypt("HELLO", [][]int{{3, 3}, {2, 5}})
+	res4, err := HillDecrypt(res3, [][]int{{3, 3}, {2, 5}})
+	if len(res3) != 6 || res4 != "HELLOX" || err != nil {
+		t.Errorf("Got incorrect padded Hill round trip: %v, %v (%v)", res3, res4, err)
+	}
+	res5, _ := HillEncrypt("HELLO", [][]int{{3, 3}, {2, 5}}, WithPadding('q'))
+	res6, _ := HillDecrypt(res5, [][]int{{3, 3}, {2, 5}})
+	if res6 != "HELLOQ" {
+		t.Errorf("Got incorrect Hill round trip with a Q for padding: %v", res6)
+	}
+
+	// Determinants that share a factor with 26 can't be undone
+	for _, bad := range [][][]int{{{2, 0}, {0, 1}}, {{1, 2}, {3, 6}}, {{13, 1}, {0, 1}}, {{1, 2, 3}, {4, 5, 6}}, {}} {
+		if _, err := HillEncrypt("HELLO", bad); err == nil {
+			t.Errorf("Encrypted with key matrix %v", bad)
+		}
+	}
+	if _, err := HillMatrix("HILL CIPHER"); err == nil {
+		t.Errorf("Made a key matrix out of 10 letters")
+	}
+}
+
+func TestPlayfair(t *testing.T) {
+	const PLAINTEXT string	= "Hide the gold in the tree stump"
+	const CIPHERTEXT string	= "BMODZBXDNABEKUDMUIXMMOUVIF"
+
+	res1, err := PlayfairEncrypt(PLAINTEXT, "Playfair example")
+	if res1 != CIPHERTEXT || err != nil {
+		t.Errorf("Got incorrect string from Playfair encryption: %v (%v)", res1, err)
+	}
+	res2, err := PlayfairDecrypt(res1, "Playfair example")
+	if res2 != "HIDETHEGOLDINTHETREXESTUMP" || err != nil {
+		t.Errorf("Got incorrect string from Playfair decryption: %v (%v)", res2, err)
+	}
+
+	square, _ := playfairSquare("Playfair example", PlayfairAlphabet)
+	if string(square) != "PLAYFIREXMBCDGHKNOQSTUVWZ" {
+		t.Errorf("Got incorrect Playfair square: %v", string(square))
+	}
+
+	// J is written as I, doubled fillers get the next letter along, and the last letter gets a filler of its own
+	for _, test := range []struct {
+		plaintext string
+		filler rune
+		want string
+	}{
+		{"JIXXA", 0, "IXIXXA"},
+		{"XXA", 0, "XYXA"},
+		{"QQA", 'q', "QRQA"},
+		{"ABBBCD", 'q', "ABBQBCDQ"},
+	} {
+		res3, _ := PlayfairEncrypt(test.plaintext, "Playfair example", WithPadding(test.filler))
+		if res4, _ := PlayfairDecrypt(res3, "Playfair example"); res4 != test.want {
+			t.Errorf("Got incorrect fillers for %v: %v", test.plaintext, res4)
+		}
+	}
+
+	// Any square alphabet works, merging whichever letters it folds
+	noq, _ := NewFoldedAlphabet("ABCDEFGHIJKLMNOPRSTUVWXYZ", map[rune]rune{'Q': 'K'})
+	res7, _ := PlayfairEncrypt("Quick jump", "Playfair example", WithAlphabet(noq))
+	res8, err := PlayfairDecrypt(res7, "Playfair example", WithAlphabet(noq))
+	if res8 != "KUICKJUMPX" || err != nil {
+		t.Errorf("Got incorrect string with Q written as K: %v (%v)", res8, err)
+	}
+	res9, _ := PlayfairEncrypt("Quick jump 42", "Playfair example 6x6", WithAlphabet(AlphanumericAlphabet))
+	res10, err := PlayfairDecrypt(res9, "Playfair example 6x6", WithAlphabet(AlphanumericAlphabet))
+	if res10 != "QUICKJUMP42X" || err != nil {
+		t.Errorf("Got incorrect string from a 6x6 square: %v (%v)", res10, err)
+	}
+
+	if _, err := PlayfairEncrypt(PLAINTEXT, "Playfair example", WithAlphabet(LatinAlphabet)); err == nil {
+		t.Errorf("Encrypted with a 23 letter alphabet")
+	}
+	if _, err := PlayfairEncrypt(PLAINTEXT, "Playfair example", WithPadding('!')); err == nil {
+		t.Errorf("Encrypted with a filler that isn't a letter")
+	}
+	if _, err := PlayfairDecrypt("BMO", "Playfair example"); err == nil {
+		t.Errorf("Decrypted an odd number of letters")
+	}
+}
@@ -0,0 +1,404 @@
+/** CIPHER REGISTRY
+
+Having every cipher behind the Cipher interface is only half of the story; something still has to decide *which* cipher to build.
+Rather than having every program that uses this package write its own big switch statement, ciphers are registered here under a
+stable name and built from a plain map of parameters. Everything in the map is a string so it can come straight from a config file
+or the command line
+
+Every built in cipher takes its key (if it has one) as the "key" parameter, written the same way the cipher's Key() method writes
+it. That means a generated key can be saved with Key() and handed back to NewCipher later to get the same cipher again. Ciphers that
+work on letters also take an "alphabet" parameter, which is either the name of one of the built in alphabets (see Alphabets) or the
+letters of the alphabet written out in order. The two exceptions are Hill, whose algebra only works over A-Z (see HillEncrypt), and
+homophonic, which enciphers every character of the message whatever it is, so both reject "alphabet" like any other parameter they
+don't understand. Substitution ciphers take a "preserve" parameter too, which turns on PreserveFormat when it's "true", and the
+columnar transpositions, Hill and Playfair take a "padding" parameter, which is the null letter for WithPadding. The book cipher's
+numbering scheme is set with the "zerobased", "joinhyphens" and "letters" parameters, one for each field of BookScheme
+*/
+
+package ciphers
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Builds a cipher out of a parameter map. Factories should reject parameters they don't understand
+type CipherFactory func(params map[string]string) (Cipher, error)
+
+var (
+	registrymu sync.RWMutex
+	registry map[string]CipherFactory = make(map[string]CipherFactory)
+)
+
+// Make a cipher available through NewCipher. Names are case sensitive, and registering the same name twice is an error
+func RegisterCipher(name string, factory CipherFactory) error {
+    if len(name) <= 0 {return errors.New("given empty name")}
+    if factory == nil {return errors.New("given nil factory")}
+
+    registrymu.Lock()
+    defer registrymu.Unlock()
+
+    if _, exists := registry[name]; exists {return errors.New("cipher \"" + name + "\" is already registered")}
+    registry[name] = factory
+    return nil
+}
+
+// Build the cipher registered under name. A nil params map is treated the same as an empty one
+func NewCipher(name string, params map[string]string) (Cipher, error) {
+    registrymu.RLock()
+    factory, exists := registry[name]
+    registrymu.RUnlock()
+
+    if !exists {return nil, errors.New("no cipher registered as \"" + name + "\"")}
+    if params == nil {params = map[string]string{}}
+
+    return factory(params)
+}
+
+// Names of every registered cipher, sorted
+func RegisteredCiphers() []string {
+    registrymu.RLock()
+    defer registrymu.RUnlock()
+
+    var names []string = make([]string, 0, len(registry))
+    for name := range registry {
+        names = append(names, name)
+    }
+    slices.Sort(names)
+
+    return names
+}
+
+// Make sure params only contains the given names, so that a typo in a config file doesn't silently get ignored
+func checkParams(params map[string]string, allowed ...string) error {
+    for name := range params {
+        if !slices.Contains(allowed, name) {return errors.New("unknown parameter \"" + name + "\"")}
+    }
+    return nil
+}
+
+// Same as checkParams, but also requires that the "key" parameter is set
+func requireKey(params map[string]string, allowed ...string) (string, error) {
+    if err := checkParams(params, allowed...); err != nil {return "", err}
+
+    key, exists := params["key"]
+    if !exists || len(key) <= 0 {return "", errors.New("missing \"key\" parameter")}
+    return key, nil
+}
+
+// The built in alphabets, by the name the "alphabet" parameter knows them as
+var Alphabets map[string]*Alphabet = map[string]*Alphabet{
+    "roman":        RomanAlphabet,
+    "alphanumeric": AlphanumericAlphabet,
+    "latin":        LatinAlphabet,
+    "playfair":     PlayfairAlphabet,
+    "greek":        GreekAlphabet,
+    "cyrillic":     CyrillicAlphabet,
+    "hebrew":       HebrewAlphabet,
+}
+
+// Turn the "alphabet", "padding" and "preserve" parameters into options for the cipher. Whether the cipher takes them at all is up to
+// checkParams
+func optionParams(params map[string]string) ([]Option, error) {
+    var opts []Option
+
+    if name := params["alphabet"]; len(name) > 0 {
+        alphabet, exists := Alphabets[name]
+        if !exists {
+            var err error
+            alphabet, err = NewAlphabet(name)
+            if err != nil {return nil, errors.New("alphabet \"" + name + "\" is not a known alphabet or a valid list of letters: " + err.Error())}
+        }
+        opts = append(opts, WithAlphabet(alphabet))
+    }
+
+    if value := params["padding"]; len(value) > 0 {
+        if utf8.RuneCountInString(value) != 1 {return nil, errors.New("padding \"" + value + "\" is not a single letter")}
+        opts = append(opts, WithPadding([]rune(value)[0]))
+    }
+
+    if value := params["preserve"]; len(value) > 0 {
+        preserve, err := strconv.ParseBool(value)
+        if err != nil {return nil, errors.New("preserve \"" + value + "\" is not true or false")}
+        if preserve {opts = append(opts, PreserveFormat())}
+    }
+
+    return opts, nil
+}
+
+// Read back a key written by formatRuneMap
+func parseRuneMap(text string) (map[rune]rune, error) {
+    var fields []string = strings.Fields(text)
+    if len(fields) <= 0 {return nil, errors.New("given empty key")}
+    var key map[rune]rune = make(map[rune]rune, len(fields))
+
+    for _, field := range fields {
+        if utf8.RuneCountInString(field) != 2 {return nil, errors.New("key pair \"" + field + "\" is not exactly 2 letters")}
+        pair := []rune(field)
+        key[pair[0]] = pair[1]
+    }
+
+    return key, nil
+}
+
+// Read back a key written by formatSymbolMap
+func parseSymbolMap(text string) (map[string]rune, error) {
+    text = strings.TrimSpace(text)
+    if len(text) <= 0 {return nil, errors.New("given empty key")}
+    var key map[string]rune = make(map[string]rune)
+
+    for len(text) > 0 {
+        // Every entry looks like `symbol:'c'`, so grab the symbol, then let strconv deal with the quoting
+        colon := strings.IndexRune(text, ':')
+        if colon <= 0 {return nil, errors.New("could not find symbol in \"" + text + "\"")}
+        symbol := text[:colon]
+
+        quoted, err := strconv.QuotedPrefix(text[colon + 1:])
+        if err != nil {return nil, errors.New("could not read letter for symbol " + symbol)}
+        letter, err := strconv.Unquote(quoted)
+        if err != nil || utf8.RuneCountInString(letter) != 1 {return nil, errors.New("could not read letter for symbol " + symbol)}
+
+        key[symbol] = []rune(letter)[0]
+        text = strings.TrimLeftFunc(text[colon + 1 + len(quoted):], unicode.IsSpace)
+    }
+
+    return key, nil
+}
+
+// Read back a key written by RailfenceCipher.Key(). The offset can be left off, in which case it's 0
+func parseRailKey(text string) (int, int, error) {
+    railtext, offsettext, found := strings.Cut(text, ",")
+    rails, err := strconv.Atoi(strings.TrimSpace(railtext))
+    if err != nil {return 0, 0, errors.New("rails \"" + railtext + "\" is not a number")}
+
+    var offset int
+    if found {
+        offset, err = strconv.Atoi(strings.TrimSpace(offsettext))
+        if err != nil {return 0, 0, errors.New("offset \"" + offsettext + "\" is not a number")}
+    }
+    if err := checkRails(rails, offset); err != nil {return 0, 0, err}
+
+    return rails, offset, nil
+}
+
+// An affine key written "MULTIPLIER,OFFSET"
+func parseAffineKey(text string) (AffineKey, error) {
+    multipliertext, offsettext, found := strings.Cut(text, ",")
+    if !found {return AffineKey{}, errors.New("affine key \"" + text + "\" needs a multiplier and an offset, separated by a comma")}
+    multiplier, err := strconv.Atoi(strings.TrimSpace(multipliertext))
+    if err != nil {return AffineKey{}, errors.New("multiplier \"" + multipliertext + "\" is not a number")}
+    offset, err := strconv.Atoi(strings.TrimSpace(offsettext))
+    if err != nil {return AffineKey{}, errors.New("offset \"" + offsettext + "\" is not a number")}
+
+    return AffineKey{Multiplier: multiplier, Offset: offset}, nil
+}
+
+func init() {
+    var builtins map[string]CipherFactory = map[string]CipherFactory{
+        "railfence": func(params map[string]string) (Cipher, error) {
+            if err := checkParams(params, "key", "alphabet"); err != nil {return nil, err}
+            opts, err := optionParams(params)
+            if err != nil {return nil, err}
+            if len(params["key"]) <= 0 {return &RailfenceCipher{Options: opts}, nil}
+
+            rails, offset, err := parseRailKey(params["key"])
+            if err != nil {return nil, err}
+            return &RailfenceCipher{Rails: rails, Offset: offset, Options: opts}, nil
+        },
+
+        "columnar": func(params map[string]string) (Cipher, error) {
+            key, err := requireKey(params, "key", "alphabet", "padding")
+            if err != nil {return nil, err}
+            opts, err := optionParams(params)
+            if err != nil {return nil, err}
+            return &ColumnarCipher{Keyword: key, Options: opts}, nil
+        },
+
+        "doublecolumnar": func(params map[string]string) (Cipher, error) {
+            key, err := requireKey(params, "key", "alphabet", "padding")
+            if err != nil {return nil, err}
+            opts, err := optionParams(params)
+            if err != nil {return nil, err}
+            first, second, found := strings.Cut(key, ",")
+            if !found || len(first) <= 0 || len(second) <= 0 {return nil, errors.New("key \"" + key + "\" should look like FIRST,SECOND")}
+            return &DoubleColumnarCipher{First: first, Second: second, Options: opts}, nil
+        },
+
+        "mvpc": func(params map[string]string) (Cipher, error) {
+            if err := checkParams(params, "key", "alphabet", "preserve"); err != nil {return nil, err}
+            opts, err := optionParams(params)
+            if err != nil {return nil, err}
+            if len(params["key"]) <= 0 {return &MVPCCipher{Options: opts}, nil}
+
+            pairs, err := parseRuneMap(params["key"])
+            if err != nil {return nil, err}
+            return &MVPCCipher{Pairs: pairs, Options: opts}, nil
+        },
+
+        "rotx": func(params map[string]string) (Cipher, error) {
+            key, err := requireKey(params, "key", "alphabet", "preserve")
+            if err != nil {return nil, err}
+            opts, err := optionParams(params)
+            if err != nil {return nil, err}
+
+            offset, err := strconv.ParseInt(key, 10, 32)
+            if err != nil {return nil, errors.New("offset \"" + key + "\" is not a number")}
+            return &ROTXCipher{Offset: rune(offset), Options: opts}, nil
+        },
+
+        "caesar": func(params map[string]string) (Cipher, error) {
+            if err := checkParams(params, "alphabet", "preserve"); err != nil {return nil, err}
+            opts, err := optionParams(params)
+            if err != nil {return nil, err}
+            return &CaesarCipher{Options: opts}, nil
+        },
+
+        "keyphrase": func(params map[string]string) (Cipher, error) {
+            key, err := requireKey(params, "key", "alphabet", "preserve")
+            if err != nil {return nil, err}
+            opts, err := optionParams(params)
+            if err != nil {return nil, err}
+            return &KeyphraseCipher{Keyphrase: key, Options: opts}, nil
+        },
+
+        "atbash": func(params map[string]string) (Cipher, error) {
+            if err := checkParams(params, "alphabet", "preserve"); err != nil {return nil, err}
+            opts, err := optionParams(params)
+            if err != nil {return nil, err}
+            return &AtbashCipher{Options: opts}, nil
+        },
+
+        "affine": func(params map[string]string) (Cipher, error) {
+            key, err := requireKey(params, "key", "alphabet", "preserve")
+            if err != nil {return nil, err}
+            opts, err := optionParams(params)
+            if err != nil {return nil, err}
+
+            affinekey, err := parseAffineKey(key)
+            if err != nil {return nil, err}
+            // Whether the multiplier can be undone depends on the alphabet, so check it now instead of on the first Encrypt
+            if _, err := modInverse(affinekey.Multiplier, getOptions(opts).alphabet.Len()); err != nil {return nil, err}
+            return &AffineCipher{AffineKey: affinekey, Options: opts}, nil
+        },
+
+        "homophonic": func(params map[string]string) (Cipher, error) {
+            if err := checkParams(params, "key", "symbolrange"); err != nil {return nil, err}
+            var c *HomophonicCipher = &HomophonicCipher{SymbolRange: 1000}
+
+            if len(params["symbolrange"]) > 0 {
+                symbolrange, err := strconv.Atoi(params["symbolrange"])
+                if err != nil || symbolrange <= 0 {return nil, errors.New("symbol range \"" + params["symbolrange"] + "\" is not a positive number")}
+                c.SymbolRange = symbolrange
+            }
+            if len(params["key"]) > 0 {
+                symbols, err := parseSymbolMap(params["key"])
+                if err != nil {return nil, err}
+                c.Symbols = symbols
+            }
+
+            return c, nil
+        },
+
+        "book": func(params map[string]string) (Cipher, error) {
+            key, err := requireKey(params, "key", "alphabet", "zerobased", "joinhyphens", "letters")
+            if err != nil {return nil, err}
+            opts, err := optionParams(params)
+            if err != nil {return nil, err}
+
+            var scheme BookScheme
+            for name, field := range map[string]*bool{"zerobased": &scheme.ZeroBased, "joinhyphens": &scheme.JoinHyphens, "letters": &scheme.Letters} {
+                if len(params[name]) <= 0 {continue}
+                *field, err = strconv.ParseBool(params[name])
+                if err != nil {return nil, errors.New(name + " \"" + params[name] + "\" is not true or false")}
+            }
+
+            return &BookCipher{Keytext: key, Scheme: scheme, Options: opts}, nil
+        },
+
+        "hill": func(params map[string]string) (Cipher, error) {
+            key, err := requireKey(params, "key", "padding")
+            if err != nil {return nil, err}
+            opts, err := optionParams(params)
+            if err != nil {return nil, err}
+
+            matrix, err := HillMatrix(key)
+            if err != nil {return nil, err}
+            if _, err := hillInverse(matrix); err != nil {return nil, err}
+            return &HillCipher{Matrix: matrix, Options: opts}, nil
+        },
+
+        "playfair": func(params map[string]string) (Cipher, error) {
+            key, err := requireKey(params, "key", "alphabet", "padding")
+            if err != nil {return nil, err}
+            opts, err := optionParams(params)
+            if err != nil {return nil, err}
+            return &PlayfairCipher{Keyphrase: key, Options: opts}, nil
+        },
+
+        "vigenere": func(params map[string]string) (Cipher, error) {
+            key, err := requireKey(params, "key", "alphabet", "preserve")
+            if err != nil {return nil, err}
+            opts, err := optionParams(params)
+            if err != nil {return nil, err}
+            return &VigenereCipher{Keytext: key, Options: opts}, nil
+        },
+
+        "beaufort": func(params map[string]string) (Cipher, error) {
+            key, err := requireKey(params, "key", "alphabet", "preserve")
+            if err != nil {return nil, err}
+            opts, err := optionParams(params)
+            if err != nil {return nil, err}
+            return &BeaufortCipher{Keytext: key, Options: opts}, nil
+        },
+
+        "variantbeaufort": func(params map[string]string) (Cipher, error) {
+            key, err := requireKey(params, "key", "alphabet", "preserve")
+            if err != nil {return nil, err}
+            opts, err := optionParams(params)
+            if err != nil {return nil, err}
+            return &VariantBeaufortCipher{Keytext: key, Options: opts}, nil
+        },
+
+        "gronsfeld": func(params map[string]string) (Cipher, error) {
+            key, err := requireKey(params, "key", "alphabet", "preserve")
+            if err != nil {return nil, err}
+            opts, err := optionParams(params)
+            if err != nil {return nil, err}
+            return &GronsfeldCipher{Digits: key, Options: opts}, nil
+        },
+
+        "autokey": func(params map[string]string) (Cipher, error) {
+            key, err := requireKey(params, "key", "alphabet", "preserve")
+            if err != nil {return nil, err}
+            opts, err := optionParams(params)
+            if err != nil {return nil, err}
+            return &AutokeyCipher{Primer: key, Options: opts}, nil
+        },
+
+        "runningkey": func(params map[string]string) (Cipher, error) {
+            key, err := requireKey(params, "key", "alphabet", "preserve")
+            if err != nil {return nil, err}
+            opts, err := optionParams(params)
+            if err != nil {return nil, err}
+            return &RunningKeyCipher{Keytext: key, Options: opts}, nil
+        },
+
+        "otp": func(params map[string]string) (Cipher, error) {
+            if err := checkParams(params, "key", "alphabet", "preserve"); err != nil {return nil, err}
+            opts, err := optionParams(params)
+            if err != nil {return nil, err}
+            if len(params["key"]) <= 0 {return &OTPCipher{Options: opts}, nil}
+            return &OTPCipher{Pad: []rune(params["key"]), Options: opts}, nil
+        },
+    }
+
+    for name, factory := range builtins {
+        if err := RegisterCipher(name, factory); err != nil {panic(fmt.Sprintf("could not register %v: %v", name, err))}
+    }
+}
@@ -0,0 +1,156 @@
+package ciphers
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestRegistry(t *testing.T) {
+	const PLAINTEXT string = "THYSECRETISTHYPRISONERIFTHOULETITGOTHOUARTAPRISONERTOIT"
+
+	params := map[string]map[string]string{
+		"railfence":	nil,
+		"columnar":		{"key": "ZEBRAS"},
+		"doublecolumnar":	{"key": "ZEBRAS,STRIPE"},
+		"mvpc":			{},
+		"rotx":			{"key": "14"},
+		"caesar":		nil,
+		"keyphrase":	{"key": "JULIUS CAESAR"},
+		"atbash":		nil,
+		"affine":		{"key": "5,8"},
+		"homophonic":	{"symbolrange": "500"},
+		"book":			{"key": "Any bright cat dances eagerly, for good hats increase joy; kind lions make noble owls purr quietly, running swiftly to umbrellas very warm, xenial yellow zebras"},
+		"hill":			{"key": "MATRIXMULTIPLICATIONWORKS", "padding": "Q"},
+		"vigenere":		{"key": "ANDYETEMANCIPATEDITMUSTBE"},
+		"beaufort":		{"key": "FORTIFICATION"},
+		"variantbeaufort":	{"key": "LEMON", "preserve": "true"},
+		"gronsfeld":	{"key": "31415"},
+		"autokey":		{"key": "QUEENLY"},
+		"runningkey":	{"key": "When in the Course of human events, it becomes necessary for one people to dissolve the political bands"},
+		"otp":			{},
+	}
+
+	for name, param := range params {
+		if !slices.Contains(RegisteredCiphers(), name) {
+			t.Errorf("Cipher %v is not registered", name)
+		}
+
+		c, err := NewCipher(name, param)
+		if c == nil || err != nil {
+			t.Fatalf("Could not build cipher %v: %v", name, err)
+		}
+		if c.Name() != name {
+			t.Errorf("Cipher registered as %v calls itself %v", name, c.Name())
+		}
+
+		res1, err := c.Encrypt(PLAINTEXT)
+		if len(res1) <= 0 || err != nil {
+			t.Errorf("Got incorrect string from %v encryption: %v (%v)", name, res1, err)
+		}
+
+		// Rebuild the cipher from its own key, which is how generated keys get saved and loaded
+		var rebuilt map[string]string = map[string]string{}
+		if len(c.Key()) > 0 {
+			rebuilt["key"] = c.Key()
+		}
+		c2, err := NewCipher(name, rebuilt)
+		if err != nil {
+			t.Fatalf("Could not rebuild cipher %v from key %v: %v", name, c.Key(), err)
+		}
+
+		res2, err := c2.Decrypt(res1)
+		if res2 != PLAINTEXT || err != nil {
+			t.Errorf("Got incorrect string from rebuilt %v decryption: %v (%v)", name, res2, err)
+		}
+	}
+
+	// Playfair leaves its fillers in, so it can't go in the table above
+	c, err := NewCipher("playfair", map[string]string{"key": "PLAYFAIR EXAMPLE", "alphabet": "playfair", "padding": "Q"})
+	if err != nil {
+		t.Fatalf("Could not build cipher playfair: %v", err)
+	}
+	res1, _ := c.Encrypt("Hide the gold in the tree stump")
+	res2, err := c.Decrypt(res1)
+	if res2 != "HIDETHEGOLDINTHETREQESTUMP" || err != nil {
+		t.Errorf("Got incorrect string from playfair decryption: %v (%v)", res2, err)
+	}
+}
+
+func TestRegistryErrors(t *testing.T) {
+	if _, err := NewCipher("enigma", nil); err == nil {
+		t.Errorf("Built a cipher that isn't registered")
+	}
+	if _, err := NewCipher("rotx", nil); err == nil {
+		t.Errorf("Built ROTX without an offset")
+	}
+	if _, err := NewCipher("rotx", map[string]string{"key": "three"}); err == nil {
+		t.Errorf("Built ROTX with a non-numeric offset")
+	}
+	for _, key := range []string{"three", "3,x", "3,4", "0"} {
+		if _, err := NewCipher("railfence", map[string]string{"key": key}); err == nil {
+			t.Errorf("Built a rail fence with key %v", key)
+		}
+	}
+	for _, key := range []string{"5", "x,8", "5,y", "2,8"} {
+		if _, err := NewCipher("affine", map[string]string{"key": key}); err == nil {
+			t.Errorf("Built an affine cipher with key %v", key)
+		}
+	}
+	for _, key := range []string{"HILLS", "AAAA"} {
+		if _, err := NewCipher("hill", map[string]string{"key": key}); err == nil {
+			t.Errorf("Built a Hill cipher with key %v", key)
+		}
+	}
+	if _, err := NewCipher("hill", map[string]string{"key": "GYBNQKURP", "alphabet": "latin"}); err == nil {
+		t.Errorf("Built a Hill cipher over an alphabet other than A-Z")
+	}
+	if _, err := NewCipher("doublecolumnar", map[string]string{"key": "ZEBRAS"}); err == nil {
+		t.Errorf("Built double columnar with only one keyword")
+	}
+	if _, err := NewCipher("columnar", map[string]string{"key": "ZEBRAS", "padding": "XY"}); err == nil {
+		t.Errorf("Built columnar with more than one null letter")
+	}
+	if c, err := NewCipher("railfence", map[string]string{"key": "3"}); err != nil || c.Key() != "3,0" {
+		t.Errorf("Got incorrect rail fence from a key with no offset: %v (%v)", c, err)
+	}
+	if _, err := NewCipher("caesar", map[string]string{"offset": "3"}); err == nil {
+		t.Errorf("Built Caesar with a parameter it doesn't take")
+	}
+	if err := RegisterCipher("caesar", func(map[string]string) (Cipher, error) {return &CaesarCipher{}, nil}); err == nil {
+		t.Errorf("Registered the same name twice")
+	}
+}
+
+func TestRegisterCipher(t *testing.T) {
+	// The registry is global, so only register once even if the test is run more than once
+	if !slices.Contains(RegisteredCiphers(), "rot13") {
+		err := RegisterCipher("rot13", func(params map[string]string) (Cipher, error) {
+			return &ROTXCipher{Offset: 13}, nil
+		})
+		if err != nil {
+			t.Fatalf("Could not register cipher: %v", err)
+		}
+	}
+
+	c, err := NewCipher("rot13", nil)
+	if err != nil {
+		t.Fatalf("Could not build registered cipher: %v", err)
+	}
+
+	res, err := c.Encrypt("HELLO")
+	if res != "URYYB" || err != nil {
+		t.Errorf("Got incorrect string from registered cipher: %v (%v)", res, err)
+	}
+}
+
+func Test_parseSymbolMap(t *testing.T) {
+	const KEY string = `7:' ' 12:'T' 103:',' 4:'\''`
+
+	res, err := parseSymbolMap(KEY)
+	if len(res) != 4 || res["7"] != ' ' || res["12"] != 'T' || res["103"] != ',' || res["4"] != '\'' || err != nil {
+		t.Errorf("Got incorrect map from parsing homophonic key: %v (%v)", res, err)
+	}
+	if formatSymbolMap(res) != `4:'\'' 7:' ' 12:'T' 103:','` {
+		t.Errorf("Homophonic key did not round trip: %v", formatSymbolMap(res))
+	}
+}
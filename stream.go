@@ -0,0 +1,436 @@
+/** STREAMING CIPHERS
+
+Every function in this package takes the whole message as one string and builds the result up a letter at a time, which is fine for
+a sentence out of The Code Book and not so fine for a few megabytes of text. The readers and writers in this file do the same work
+on an io.Reader or io.Writer a chunk at a time instead, and give back exactly what the in-memory functions would
+
+The trick is that almost every cipher here works one letter at a time, so the only thing that has to survive from one chunk to the
+next is whatever state the cipher keeps: nothing for ROTX and Atbash, the position in the key for Vigenere. The one exception is
+the Rail Fence cipher, which needs to see the end of the message before it can finish. Encryption can send the first rail along as
+it goes and only has to hold on to the others, but decryption has no idea where the second rail starts until the input runs out,
+so it has to buffer everything
+
+Input goes through the same cleanup as the in-memory functions (letters are normalized to the alphabet, everything else is
+dropped, or kept with PreserveFormat), and a multi-byte character split between two chunks is stitched back together before that
+happens. Every constructor takes the same options as the function it mirrors
+*/
+
+package ciphers
+
+import (
+	"errors"
+	"io"
+	"slices"
+	"unicode/utf8"
+)
+
+// What a streaming cipher has to do. next gets the characters of each chunk and appends whatever output is ready to out. finish
+// is called once the input has run out, for ciphers that had to hold on to some of it. save makes a copy of the state as it is
+// now, so that a writer can go back to it when the output couldn't all be written
+type streamState interface {
+	next(text []rune, out []byte) []byte
+	finish(out []byte) []byte
+	save() streamState
+}
+
+// Any cipher that only needs to look at the current letter, and how many letters came before it
+type stepState struct {
+    step func(cur rune, i int) rune
+    i int
+    o options
+}
+
+func (s *stepState) next(text []rune, out []byte) []byte {
+    return append(out, s.o.substitute(string(text), func(cur rune) rune {
+        s.i++
+        return s.step(cur, s.i - 1)
+    })...)
+}
+func (s *stepState) finish(out []byte) []byte {return out}
+func (s *stepState) save() streamState {
+    c := *s
+    return &c
+}
+
+// Split raw bytes into characters. Returns the characters and any bytes at the end that don't make up a whole character yet
+func streamRunes(data []byte, text []rune) ([]rune, []byte) {
+    for len(data) > 0 && utf8.FullRune(data) {
+        cur, size := utf8.DecodeRune(data)
+        data = data[size:]
+        text = append(text, cur)
+    }
+
+    return text, data
+}
+
+
+/* A writer can't take back what it's already handed to the cipher, so when the underlying writer fails part of the way through,
+the state has to go back to how it was before the chunk and then move forward over just the characters that made it out. That way
+Write's count is honest, and writing the rest of p again carries on with the right key letter (or rail) instead of skipping ahead.
+A character whose output only got partly written counts as written, and the rest of its output goes out first on the next Write
+*/
+
+type streamWriter struct {
+    w io.Writer
+    state streamState
+    partial []byte
+    pending []byte
+    closed bool
+}
+
+// Write out anything left over from a character that only got partly written last time
+func (s *streamWriter) flush() error {
+    for len(s.pending) > 0 {
+        n, err := s.w.Write(s.pending)
+        s.pending = s.pending[n:]
+        if err != nil {return err}
+        if n <= 0 {return io.ErrShortWrite}
+    }
+    return nil
+}
+
+func (s *streamWriter) Write(p []byte) (int, error) {
+    if s.closed {return 0, errors.New("write to closed cipher writer")}
+    if err := s.flush(); err != nil {return 0, err}
+
+    data := append(s.partial[:len(s.partial):len(s.partial)], p...)
+    text, partial := streamRunes(data, nil)
+    saved := s.state.save()
+
+    out := s.state.next(text, nil)
+    var written int
+    var err error
+    if len(out) > 0 {
+        written, err = s.w.Write(out)
+        if err == nil && written < len(out) {err = io.ErrShortWrite}
+    }
+    if err == nil {
+        s.partial = append(s.partial[:0], partial...)
+        return len(p), nil
+    }
+
+    // Go back and only take the characters whose output was (at least partly) written
+    s.state = saved
+    var consumed int
+    var replay []byte
+    for _, cur := range text {
+        before := s.state.save()
+        next := s.state.next([]rune{cur}, replay)
+        if len(next) > len(replay) && len(replay) >= written {
+            s.state = before
+            break
+        }
+
+        _, size := utf8.DecodeRune(data[consumed:])
+        consumed += size
+        replay = next
+    }
+    s.pending = append(s.pending, replay[written:]...)
+
+    // Bytes left over from the last Write were already counted then
+    if consumed <= len(s.partial) {
+        s.partial = s.partial[consumed:]
+        return 0, err
+    }
+    n := consumed - len(s.partial)
+    s.partial = s.partial[:0]
+    return n, err
+}
+
+// Flush anything the cipher was holding on to. This does not close the underlying writer. If the underlying writer fails, Close can
+// be called again to write the rest
+func (s *streamWriter) Close() error {
+    if !s.closed {
+        if err := s.flush(); err != nil {return err}
+        s.closed = true
+
+        // Anything left in partial was never a whole character, which stripnonalpha would have thrown away too
+        s.pending = s.state.finish(nil)
+    }
+    return s.flush()
+}
+
+
+// How many reads in a row can come back with nothing before a reader gives up, the same as bufio
+const MAXEMPTYREADS int = 100
+
+type streamReader struct {
+    r io.Reader
+    state streamState
+    partial []byte
+    pending []byte
+    chunk []byte
+    err error
+}
+
+func (s *streamReader) Read(p []byte) (int, error) {
+    var empty int
+    for len(s.pending) <= 0 {
+        if s.err != nil {return 0, s.err}
+
+        n, err := s.r.Read(s.chunk)
+        if n == 0 && err == nil {
+            // A reader that never gets anywhere would otherwise keep this loop going forever
+            empty++
+            if empty >= MAXEMPTYREADS {return 0, io.ErrNoProgress}
+            continue
+        }
+        empty = 0
+        text, partial := streamRunes(append(s.partial, s.chunk[:n]...), nil)
+        s.partial = append(s.partial[:0], partial...)
+        s.pending = s.state.next(text, s.pending)
+
+        if err != nil {
+            if err == io.EOF {s.pending = s.state.finish(s.pending)}
+            s.err = err
+        }
+    }
+
+    n := copy(p, s.pending)
+    s.pending = s.pending[n:]
+    return n, nil
+}
+
+func newStreamWriter(w io.Writer, state streamState) io.WriteCloser {
+    return &streamWriter{w: w, state: state}
+}
+
+func newStreamReader(r io.Reader, state streamState) io.Reader {
+    return &streamReader{r: r, state: state, chunk: make([]byte, 4096)}
+}
+
+
+func rotxState(offset rune, o options) (streamState, error) {
+    if offset % rune(o.alphabet.Len()) == 0 {return nil, errors.New("given offset that would not meaningfully encrypt message")}
+
+    return &stepState{step: func(cur rune, i int) rune {
+        ind, _ := o.alphabet.Index(cur)
+        return o.alphabet.Rune(ind + int(offset))
+    }, o: o}, nil
+}
+
+// Streaming version of ROTX. Writes to w are encrypted, and nothing reaches w until the letters are encrypted
+func NewROTXWriter(w io.Writer, offset rune, opts ...Option) (io.WriteCloser, error) {
+    o := getOptions(opts)
+    state, err := rotxState(offset, o)
+    if err != nil {return nil, err}
+    return newStreamWriter(w, state), nil
+}
+
+// Streaming version of ROTX. Reads give back the encrypted contents of r
+func NewROTXReader(r io.Reader, offset rune, opts ...Option) (io.Reader, error) {
+    o := getOptions(opts)
+    state, err := rotxState(offset, o)
+    if err != nil {return nil, err}
+    return newStreamReader(r, state), nil
+}
+
+
+func atbashState(o options) streamState {
+    return &stepState{step: func(cur rune, i int) rune {
+        ind, _ := o.alphabet.Index(cur)
+        return o.alphabet.Rune(o.alphabet.Len() - 1 - ind)
+    }, o: o}
+}
+
+func NewAtbashWriter(w io.Writer, opts ...Option) io.WriteCloser {
+    return newStreamWriter(w, atbashState(getOptions(opts)))
+}
+
+func NewAtbashReader(r io.Reader, opts ...Option) io.Reader {
+    return newStreamReader(r, atbashState(getOptions(opts)))
+}
+
+
+// Works with any key keymapProcess would, such as the ones MVPCEncrypt generates. Letters the key doesn't cover are left alone,
+// the same way MVPCEncrypt leaves them
+func keymapState(key map[rune]rune, o options) (streamState, error) {
+    if len(key) <= 0 {return nil, errors.New("given empty key")}
+
+    return &stepState{step: func(cur rune, i int) rune {
+        if pair, exists := key[cur]; exists {return pair}
+        return cur
+    }, o: o}, nil
+}
+
+func NewKeymapWriter(w io.Writer, key map[rune]rune, opts ...Option) (io.WriteCloser, error) {
+    state, err := keymapState(key, getOptions(opts))
+    if err != nil {return nil, err}
+    return newStreamWriter(w, state), nil
+}
+
+func NewKeymapReader(r io.Reader, key map[rune]rune, opts ...Option) (io.Reader, error) {
+    state, err := keymapState(key, getOptions(opts))
+    if err != nil {return nil, err}
+    return newStreamReader(r, state), nil
+}
+
+
+// Same arithmetic as VigenereEncrypt/VigenereDecrypt, but the position in the key is kept between chunks
+func vigenereState(keytext string, decrypt bool, o options) (streamState, error) {
+    if len(keytext) <= 0 {return nil, errors.New("given empty key")}
+    key, err := vigenereKey(keytext, o.alphabet)
+    if err != nil {return nil, err}
+
+    return &stepState{step: func(cur rune, i int) rune {
+        ind, _ := o.alphabet.Index(cur)
+        k := key[i%len(key)]
+
+        if decrypt {return o.alphabet.Rune(o.tableau.decrypt(ind, k))}
+        return o.alphabet.Rune(o.tableau.encrypt(ind, k))
+    }, o: o}, nil
+}
+
+// Streaming version of VigenereEncrypt
+func NewVigenereWriter(w io.Writer, keytext string, opts ...Option) (io.WriteCloser, error) {
+    o := getOptions(opts)
+    state, err := vigenereState(keytext, false, o)
+    if err != nil {return nil, err}
+    return newStreamWriter(w, state), nil
+}
+
+// Streaming version of VigenereEncrypt
+func NewVigenereReader(r io.Reader, keytext string, opts ...Option) (io.Reader, error) {
+    o := getOptions(opts)
+    state, err := vigenereState(keytext, false, o)
+    if err != nil {return nil, err}
+    return newStreamReader(r, state), nil
+}
+
+// Streaming version of VigenereDecrypt
+func NewVigenereDecryptWriter(w io.Writer, keytext string, opts ...Option) (io.WriteCloser, error) {
+    o := getOptions(opts)
+    state, err := vigenereState(keytext, true, o)
+    if err != nil {return nil, err}
+    return newStreamWriter(w, state), nil
+}
+
+// Streaming version of VigenereDecrypt
+func NewVigenereDecryptReader(r io.Reader, keytext string, opts ...Option) (io.Reader, error) {
+    o := getOptions(opts)
+    state, err := vigenereState(keytext, true, o)
+    if err != nil {return nil, err}
+    return newStreamReader(r, state), nil
+}
+
+
+// Rail Fence encryption: the first rail goes straight through, and the rest wait until the end. Moving letters around doesn't
+// leave anywhere sensible for punctuation to go, so PreserveFormat doesn't apply
+type railfenceState struct {
+    rails [][]byte
+    count int
+    offset int
+    o options
+}
+
+func (s *railfenceState) next(text []rune, out []byte) []byte {
+    for _, cur := range text {
+        cur, ok := s.o.alphabet.Normalize(cur)
+        if !ok {continue}
+
+        if rail := railOf(s.count, len(s.rails), s.offset); rail == 0 {
+            out = utf8.AppendRune(out, cur)
+        } else {
+            s.rails[rail] = utf8.AppendRune(s.rails[rail], cur)
+        }
+        s.count++
+    }
+    return out
+}
+
+func (s *railfenceState) finish(out []byte) []byte {
+    for i := 1; i < len(s.rails); i++ {
+        out = append(out, s.rails[i]...)
+        s.rails[i] = nil
+    }
+    return out
+}
+
+// The rails are only ever appended to, so the copy can share what's already in them
+func (s *railfenceState) save() streamState {
+    c := *s
+    c.rails = slices.Clone(s.rails)
+    return &c
+}
+
+// Rail Fence decryption: every rail after the first starts somewhere in the middle of the ciphertext, and there's no knowing where
+// until the ciphertext is over
+type railfenceDecryptState struct {
+    letters []rune
+    rails int
+    offset int
+    o options
+}
+
+func (s *railfenceDecryptState) next(text []rune, out []byte) []byte {
+    for _, cur := range text {
+        cur, ok := s.o.alphabet.Normalize(cur)
+        if !ok {continue}
+        s.letters = append(s.letters, cur)
+    }
+    return out
+}
+
+func (s *railfenceDecryptState) finish(out []byte) []byte {
+    var res []rune = make([]rune, len(s.letters))
+    for i, ind := range railOrder(len(s.letters), s.rails, s.offset) {
+        res[ind] = s.letters[i]
+    }
+    s.letters = nil
+
+    return append(out, string(res)...)
+}
+
+// letters is only ever appended to, so the copy can share it
+func (s *railfenceDecryptState) save() streamState {
+    c := *s
+    return &c
+}
+
+// Streaming version of RailfenceEncrypt. Half of the message is held in memory until Close
+func NewRailfenceWriter(w io.Writer, opts ...Option) io.WriteCloser {
+    res, _ := NewRailfenceWriterN(w, 2, 0, opts...)
+    return res
+}
+
+// Streaming version of RailfenceEncrypt. Half of the message is held in memory until r runs out
+func NewRailfenceReader(r io.Reader, opts ...Option) io.Reader {
+    res, _ := NewRailfenceReaderN(r, 2, 0, opts...)
+    return res
+}
+
+// Streaming version of RailfenceDecrypt. The whole message is held in memory until Close
+func NewRailfenceDecryptWriter(w io.Writer, opts ...Option) io.WriteCloser {
+    res, _ := NewRailfenceDecryptWriterN(w, 2, 0, opts...)
+    return res
+}
+
+// Streaming version of RailfenceDecrypt. The whole message is held in memory until r runs out
+func NewRailfenceDecryptReader(r io.Reader, opts ...Option) io.Reader {
+    res, _ := NewRailfenceDecryptReaderN(r, 2, 0, opts...)
+    return res
+}
+
+// Streaming version of RailfenceEncryptN. Every rail but the first is held in memory until Close
+func NewRailfenceWriterN(w io.Writer, rails, offset int, opts ...Option) (io.WriteCloser, error) {
+    if err := checkRails(rails, offset); err != nil {return nil, err}
+    return newStreamWriter(w, &railfenceState{rails: make([][]byte, rails), offset: offset, o: getOptions(opts)}), nil
+}
+
+// Streaming version of RailfenceEncryptN. Every rail but the first is held in memory until r runs out
+func NewRailfenceReaderN(r io.Reader, rails, offset int, opts ...Option) (io.Reader, error) {
+    if err := checkRails(rails, offset); err != nil {return nil, err}
+    return newStreamReader(r, &railfenceState{rails: make([][]byte, rails), offset: offset, o: getOptions(opts)}), nil
+}
+
+// Streaming version of RailfenceDecryptN. The whole message is held in memory until Close
+func NewRailfenceDecryptWriterN(w io.Writer, rails, offset int, opts ...Option) (io.WriteCloser, error) {
+    if err := checkRails(rails, offset); err != nil {return nil, err}
+    return newStreamWriter(w, &railfenceDecryptState{rails: rails, offset: offset, o: getOptions(opts)}), nil
+}
+
+// Streaming version of RailfenceDecryptN. The whole message is held in memory until r runs out
+func NewRailfenceDecryptReaderN(r io.Reader, rails, offset int, opts ...Option) (io.Reader, error) {
+    if err := checkRails(rails, offset); err != nil {return nil, err}
+    return newStreamReader(r, &railfenceDecryptState{rails: rails, offset: offset, o: getOptions(opts)}), nil
+}
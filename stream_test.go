@@ -0,0 +1,211 @@
+package ciphers
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+// Multi-byte characters are in here on purpose, so that some of them get split between chunks
+const STREAMTEXT string = "" +
+	"AND ONE BY ONE DROPPED THE REVELLERS IN THE BLOOD-BEDEWED HALLS OF THEIR REVEL, AND DIED EACH IN THE DESPAIRING POSTURE " +
+	"OF HIS FALL. And the life of the ebony clock went out with that of the last of the gay. Ünd the flâmes of the tripods " +
+	"expired. And Darkness and Decay and the Red Death held illimitable dominion over all. — ½ ∑ 日本語"
+
+// Push text through a writer in awkwardly sized pieces
+func writeChunks(t *testing.T, w io.WriteCloser, text string, size int) {
+	for data := []byte(text); len(data) > 0; {
+		n := min(size, len(data))
+		if _, err := w.Write(data[:n]); err != nil {
+			t.Fatalf("Could not write chunk: %v", err)
+		}
+		data = data[n:]
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Could not close writer: %v", err)
+	}
+}
+
+// Only takes a few bytes at a time, and complains about the rest
+type shortWriter struct {
+	buf bytes.Buffer
+	limit int
+}
+
+func (w *shortWriter) Write(p []byte) (int, error) {
+	n, _ := w.buf.Write(p[:min(len(p), w.limit)])
+	if n < len(p) {return n, io.ErrShortWrite}
+	return n, nil
+}
+
+// Hands over a few bytes every so often, and nothing at all in between. Once r runs out it never says so, and stalls forever
+type stallingReader struct {
+	r io.Reader
+	calls int
+}
+
+func (r *stallingReader) Read(p []byte) (int, error) {
+	r.calls++
+	if r.calls % 3 != 0 {return 0, nil}
+	n, err := r.r.Read(p[:min(len(p), 5)])
+	if err == io.EOF {err = nil}
+	return n, err
+}
+
+// Push text through a writer the way a careful caller would when writes keep coming up short: try again with whatever wasn't taken
+func writeRetrying(t *testing.T, w io.WriteCloser, text string) {
+	for data, tries := []byte(text), 0; len(data) > 0; tries++ {
+		if tries > 100 * len(text) {
+			t.Fatalf("Writer stopped making progress with %v bytes left", len(data))
+		}
+		n, _ := w.Write(data[:min(7, len(data))])
+		data = data[n:]
+	}
+	for tries := 0; w.Close() != nil; tries++ {
+		if tries > 100 * len(text) {
+			t.Fatalf("Writer never finished closing")
+		}
+	}
+}
+
+func TestStreams(t *testing.T) {
+	type stream struct {
+		name string
+		expected func(string) (string, error)
+		writer func(io.Writer) (io.WriteCloser, error)
+		reader func(io.Reader) (io.Reader, error)
+	}
+
+	key := map[rune]rune{
+		'A': 'V', 'B': 'H', 'C': 'M', 'D': 'X', 'E': 'U', 'F': 'W', 'G': 'I', 'H': 'B', 'I': 'G', 'J': 'K', 'K': 'J', 'L': 'R',
+		'M': 'C', 'N': 'S', 'O': 'Q', 'P': 'Y', 'Q': 'O', 'R': 'L', 'S': 'N', 'T': 'Z', 'U': 'E', 'V': 'A', 'W': 'F', 'X': 'D',
+		'Y': 'P', 'Z': 'T',
+	}
+
+	const KEYTEXT string = "ANDYETEMANCIPATEDITMUSTBE"
+
+	streams := []stream{
+		{"rotx",
+			func(text string) (string, error) {return ROTX(text, -11)},
+			func(w io.Writer) (io.WriteCloser, error) {return NewROTXWriter(w, -11)},
+			func(r io.Reader) (io.Reader, error) {return NewROTXReader(r, -11)}},
+		{"atbash",
+			func(text string) (string, error) {return Atbash(text)},
+			func(w io.Writer) (io.WriteCloser, error) {return NewAtbashWriter(w), nil},
+			func(r io.Reader) (io.Reader, error) {return NewAtbashReader(r), nil}},
+		{"keymap",
+			func(text string) (string, error) {
+				text, err := stripnonalpha(text)
+				if err != nil {return "", err}
+				return keymapProcess(text, key)
+			},
+			func(w io.Writer) (io.WriteCloser, error) {return NewKeymapWriter(w, key)},
+			func(r io.Reader) (io.Reader, error) {return NewKeymapReader(r, key)}},
+		{"vigenere",
+			func(text string) (string, error) {return VigenereEncrypt(text, KEYTEXT)},
+			func(w io.Writer) (io.WriteCloser, error) {return NewVigenereWriter(w, KEYTEXT)},
+			func(r io.Reader) (io.Reader, error) {return NewVigenereReader(r, KEYTEXT)}},
+		{"vigenere decrypt",
+			func(text string) (string, error) {return VigenereDecrypt(text, KEYTEXT)},
+			func(w io.Writer) (io.WriteCloser, error) {return NewVigenereDecryptWriter(w, KEYTEXT)},
+			func(r io.Reader) (io.Reader, error) {return NewVigenereDecryptReader(r, KEYTEXT)}},
+		{"railfence",
+			func(text string) (string, error) {return RailfenceEncrypt(text)},
+			func(w io.Writer) (io.WriteCloser, error) {return NewRailfenceWriter(w), nil},
+			func(r io.Reader) (io.Reader, error) {return NewRailfenceReader(r), nil}},
+		{"railfence decrypt",
+			func(text string) (string, error) {return RailfenceDecrypt(text)},
+			func(w io.Writer) (io.WriteCloser, error) {return NewRailfenceDecryptWriter(w), nil},
+			func(r io.Reader) (io.Reader, error) {return NewRailfenceDecryptReader(r), nil}},
+		{"railfence 4 rails",
+			func(text string) (string, error) {return RailfenceEncryptN(text, 4, 1)},
+			func(w io.Writer) (io.WriteCloser, error) {return NewRailfenceWriterN(w, 4, 1)},
+			func(r io.Reader) (io.Reader, error) {return NewRailfenceReaderN(r, 4, 1)}},
+		{"railfence 4 rails decrypt",
+			func(text string) (string, error) {return RailfenceDecryptN(text, 4, 1)},
+			func(w io.Writer) (io.WriteCloser, error) {return NewRailfenceDecryptWriterN(w, 4, 1)},
+			func(r io.Reader) (io.Reader, error) {return NewRailfenceDecryptReaderN(r, 4, 1)}},
+	}
+
+	for _, s := range streams {
+		expected, err := s.expected(STREAMTEXT)
+		if err != nil {
+			t.Fatalf("Could not get expected %v output: %v", s.name, err)
+		}
+
+		for _, size := range []int{1, 2, 3, 7, 64, 4096} {
+			var res bytes.Buffer
+			w, err := s.writer(&res)
+			if err != nil {
+				t.Fatalf("Could not make %v writer: %v", s.name, err)
+			}
+			writeChunks(t, w, STREAMTEXT, size)
+
+			if res.String() != expected {
+				t.Errorf("Got incorrect string from %v writer with %v byte chunks: %v", s.name, size, res.String())
+			}
+		}
+
+		// Writes that come up short mustn't move the cipher along any further than what was really written
+		for _, limit := range []int{1, 2, 5} {
+			res := &shortWriter{limit: limit}
+			w, err := s.writer(res)
+			if err != nil {
+				t.Fatalf("Could not make %v writer: %v", s.name, err)
+			}
+			writeRetrying(t, w, STREAMTEXT)
+
+			if res.buf.String() != expected {
+				t.Errorf("Got incorrect string from %v writer with %v byte writes: %v", s.name, limit, res.buf.String())
+			}
+		}
+
+		for _, input := range []io.Reader{strings.NewReader(STREAMTEXT), iotest.OneByteReader(strings.NewReader(STREAMTEXT)), iotest.HalfReader(strings.NewReader(STREAMTEXT))} {
+			r, err := s.reader(input)
+			if err != nil {
+				t.Fatalf("Could not make %v reader: %v", s.name, err)
+			}
+
+			res, err := io.ReadAll(r)
+			if string(res) != expected || err != nil {
+				t.Errorf("Got incorrect string from %v reader: %v (%v)", s.name, string(res), err)
+			}
+		}
+	}
+}
+
+func TestStreamErrors(t *testing.T) {
+	if _, err := NewROTXWriter(io.Discard, 26); err == nil {
+		t.Errorf("Made a ROTX writer that doesn't encrypt anything")
+	}
+	if _, err := NewVigenereReader(strings.NewReader(STREAMTEXT), ""); err == nil {
+		t.Errorf("Made a Vigenere reader without a key")
+	}
+	if _, err := NewRailfenceWriterN(io.Discard, 3, 4); err == nil {
+		t.Errorf("Made a rail fence writer with an offset past the end of the zig-zag")
+	}
+
+	// Empty reads now and then are fine, but a reader that never gets anywhere has to be given up on instead of spinning
+	stalled, _ := NewVigenereReader(&stallingReader{r: strings.NewReader("attack at dawn")}, "LEMON")
+	buf := make([]byte, 64)
+	var got []byte
+	var err error
+	for err == nil {
+		var n int
+		n, err = stalled.Read(buf)
+		got = append(got, buf[:n]...)
+	}
+	want, _ := VigenereEncrypt("attack at dawn", "LEMON")
+	if string(got) != want || err != io.ErrNoProgress {
+		t.Errorf("Got incorrect output from a stalling reader: %q (%v)", got, err)
+	}
+
+	var res bytes.Buffer
+	w := NewAtbashWriter(&res)
+	w.Close()
+	if _, err := w.Write([]byte("ABC")); err == nil {
+		t.Errorf("Wrote to a closed writer")
+	}
+}